@@ -1,6 +1,7 @@
 package gompatible
 
 import (
+	"go/token"
 	"go/types"
 )
 
@@ -38,6 +39,11 @@ type Change interface {
 	ShowBefore() string
 	ShowAfter() string
 	Kind() ChangeKind
+	// PosBefore and PosAfter return the positions of the declarations in
+	// each revision, with the file names relative to the repository root.
+	// They return zero Position if the API does not exist in the revision.
+	PosBefore() token.Position
+	PosAfter() token.Position
 }
 
 // ShowChange returns a string represnetation of an API change.
@@ -1,7 +1,11 @@
 package gompatible
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -107,3 +111,61 @@ func TestDiffPackages(t *testing.T) {
 		assert.Equal(t, expected.String(), change.Kind().String(), ShowChange(change))
 	}
 }
+
+func TestChangePositions(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// resolve symlinks eg. /tmp on macOS as git does
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	require.NoError(t, err)
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	writeFile("lib/before/a.go", "package lib\n\nfunc F() {}\n\ntype T int\n")
+	writeFile("lib/after/a.go", "package lib\n\nfunc F(n int) {}\n\nfunc G() {}\n\ntype T int\n\nvar V = 1\n")
+
+	load := func(name string) (*DirSpec, *Package) {
+		dir := &DirSpec{Path: filepath.Join(tempDir, name), pkgOverride: "lib"}
+		pkgs, err := LoadDir(dir, false)
+		require.NoError(t, err)
+		return dir, pkgs["lib"]
+	}
+
+	// Not in a repository: file names are as loaded
+	dir1, pkg1 := load("lib/before")
+	dir2, pkg2 := load("lib/after")
+	assert.False(t, dir1.rootLooked, "repository root is looked up only for positions")
+
+	diff := DiffPackages(pkg1, pkg2)
+	assert.Equal(t, filepath.Join(tempDir, "lib", "before", "a.go")+":3:6", diff.Funcs()["F"].PosBefore().String())
+	assert.Equal(t, filepath.Join(tempDir, "lib", "after", "a.go")+":3:6", diff.Funcs()["F"].PosAfter().String())
+	assert.True(t, dir1.rootLooked)
+	assert.Equal(t, "", dir2.root)
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	// In a repository: file names are relative to the root
+	git(t, tempDir, "init", "-q")
+
+	_, pkg1 = load("lib/before")
+	_, pkg2 = load("lib/after")
+
+	diff = DiffPackages(pkg1, pkg2)
+	assert.Equal(t, filepath.Join("lib", "before", "a.go")+":3:6", diff.Funcs()["F"].PosBefore().String())
+	assert.Equal(t, filepath.Join("lib", "after", "a.go")+":3:6", diff.Funcs()["F"].PosAfter().String())
+
+	change := diff.Funcs()["G"]
+	assert.Equal(t, "-", change.PosBefore().String())
+	assert.Equal(t, filepath.Join("lib", "after", "a.go")+":5:6", change.PosAfter().String())
+
+	assert.Equal(t, filepath.Join("lib", "before", "a.go")+":5:6", diff.Types()["T"].PosBefore().String())
+	assert.Equal(t, filepath.Join("lib", "after", "a.go")+":9:5", diff.Values()["V"].PosAfter().String())
+}
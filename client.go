@@ -0,0 +1,344 @@
+package gompatible
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+)
+
+// clientWriter synthesizes a client program of a package.
+type clientWriter struct {
+	target *types.Package
+	// pkgName is the name of the package the program is written in
+	pkgName string
+	// local is the path of the package the program is written in, whose names are not qualified
+	local string
+	buf   bytes.Buffer
+	// imports are the paths of the imported packages in the order of appearance
+	imports []string
+	// aliases maps the paths of imported packages to their local names
+	aliases map[string]string
+	// names is the set of the local names used
+	names map[string]bool
+	n     int
+}
+
+func newClientWriter(target *types.Package) *clientWriter {
+	return &clientWriter{
+		target:  target,
+		pkgName: "client",
+		aliases: map[string]string{},
+		names:   map[string]bool{},
+	}
+}
+
+// qualify names the package in the client, importing it.
+func (w *clientWriter) qualify(p *types.Package) string {
+	if p.Path() == w.local {
+		return ""
+	}
+
+	if alias, ok := w.aliases[p.Path()]; ok {
+		return alias
+	}
+
+	alias := p.Name()
+	for i := 2; w.names[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+
+	w.aliases[p.Path()] = alias
+	w.names[alias] = true
+	w.imports = append(w.imports, p.Path())
+	return alias
+}
+
+func (w *clientWriter) typeString(t types.Type) string {
+	return types.TypeString(t, w.qualify)
+}
+
+// newName returns a fresh name of a variable or a type with the prefix.
+func (w *clientWriter) newName(prefix string) string {
+	name := fmt.Sprintf("%s%d", prefix, w.n)
+	w.n++
+	return name
+}
+
+// newVar declares a variable of the type in the function body and returns its name.
+func (w *clientWriter) newVar(body *bytes.Buffer, t types.Type) string {
+	name := w.newName("v")
+	fmt.Fprintf(body, "\tvar %s %s\n", name, w.typeString(t))
+	return name
+}
+
+// A clientSnippet writes a function using an API to the client.
+// Declarations required by the function can be written to the client before it.
+type clientSnippet func(w *clientWriter, body *bytes.Buffer)
+
+// writeSnippet writes the function of the snippet to the client.
+func (w *clientWriter) writeSnippet(snippet clientSnippet) {
+	var body bytes.Buffer
+	snippet(w, &body)
+
+	fmt.Fprintf(&w.buf, "func _() {\n%s}\n\n", body.String())
+}
+
+// clientSnippets returns the snippets exercising the API in various ways.
+// If exhaustive, they include the ones which may break by compatible changes too,
+// eg. unkeyed literals and implementations of interfaces, which serve only as examples.
+func clientSnippets(obj types.Object, exhaustive bool) []clientSnippet {
+	ref := func(w *clientWriter) string {
+		return w.qualify(obj.Pkg()) + "." + obj.Name()
+	}
+
+	switch obj := obj.(type) {
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		if recv := sig.Recv(); recv != nil {
+			return []clientSnippet{
+				func(w *clientWriter, body *bytes.Buffer) {
+					// Methods are called on a pointer, which has the methods of both receivers
+					t := recv.Type()
+					if _, ok := t.(*types.Pointer); !ok {
+						t = types.NewPointer(t)
+					}
+					w.exerciseCall(body, w.newVar(body, t)+"."+obj.Name(), sig)
+				},
+			}
+		}
+
+		return []clientSnippet{
+			func(w *clientWriter, body *bytes.Buffer) {
+				w.exerciseCall(body, ref(w), sig)
+			},
+		}
+
+	case *types.TypeName:
+		return typeSnippets(obj.Type(), exhaustive)
+
+	case *types.Const:
+		return []clientSnippet{
+			func(w *clientWriter, body *bytes.Buffer) {
+				w.exerciseValue(body, ref(w), obj.Type())
+			},
+		}
+
+	case *types.Var:
+		return []clientSnippet{
+			func(w *clientWriter, body *bytes.Buffer) {
+				w.exerciseValue(body, ref(w), obj.Type())
+			},
+			func(w *clientWriter, body *bytes.Buffer) {
+				// Variables can be assigned
+				fmt.Fprintf(body, "\t%s = %s\n", ref(w), w.newVar(body, obj.Type()))
+			},
+		}
+	}
+
+	return nil
+}
+
+// typeSnippets returns the snippets using the members of the type, building a value of it,
+// or converting a value of the underlying type to it. See clientSnippets for exhaustive.
+func typeSnippets(t types.Type, exhaustive bool) []clientSnippet {
+	var snippets []clientSnippet
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		var fields []*types.Var
+		unkeyable := u.NumFields() > 0
+		for i := 0; i < u.NumFields(); i++ {
+			fields = append(fields, u.Field(i))
+		}
+
+		for _, f := range fields {
+			if f.Exported() == false {
+				unkeyable = false
+				continue
+			}
+			f := f
+			snippets = append(snippets, func(w *clientWriter, body *bytes.Buffer) {
+				w.exerciseValue(body, w.newVar(body, t)+"."+f.Name(), f.Type())
+			})
+		}
+
+		if unkeyable && exhaustive {
+			snippets = append(snippets, func(w *clientWriter, body *bytes.Buffer) {
+				// An unkeyed literal, which breaks by adding fields
+				values := make([]string, len(fields))
+				for i, f := range fields {
+					values[i] = w.newVar(body, f.Type())
+				}
+				fmt.Fprintf(body, "\t_ = %s{%s}\n", w.typeString(t), strings.Join(values, ", "))
+			})
+		}
+
+	case *types.Interface:
+		var methods []*types.Func
+		implementable := true
+		for i := 0; i < u.NumMethods(); i++ {
+			methods = append(methods, u.Method(i))
+		}
+
+		for _, m := range methods {
+			if m.Exported() == false {
+				implementable = false
+				continue
+			}
+			m := m
+			snippets = append(snippets, func(w *clientWriter, body *bytes.Buffer) {
+				w.exerciseCall(body, w.newVar(body, t)+"."+m.Name(), m.Type().(*types.Signature))
+			})
+		}
+
+		if implementable && exhaustive {
+			snippets = append(snippets, func(w *clientWriter, body *bytes.Buffer) {
+				// An implementation of the interface, which breaks by adding methods
+				impl := w.newName("impl")
+				fmt.Fprintf(&w.buf, "type %s struct{}\n\n", impl)
+				for _, m := range methods {
+					sig := strings.TrimPrefix(w.typeString(m.Type()), "func")
+					fmt.Fprintf(&w.buf, "func (%s) %s%s { panic(\"not implemented\") }\n\n", impl, m.Name(), sig)
+				}
+				fmt.Fprintf(body, "\tvar _ %s = %s{}\n", w.typeString(t), impl)
+			})
+		}
+
+	default:
+		snippets = append(snippets, func(w *clientWriter, body *bytes.Buffer) {
+			fmt.Fprintf(body, "\t_ = %s(%s)\n", w.typeString(t), w.newVar(body, u))
+		})
+	}
+
+	return snippets
+}
+
+// exerciseCall calls the function with arguments of the parameter types
+// and assigns the results to variables of the result types.
+func (w *clientWriter) exerciseCall(body *bytes.Buffer, fun string, sig *types.Signature) {
+	args := make([]string, sig.Params().Len())
+	for i := range args {
+		args[i] = w.newVar(body, sig.Params().At(i).Type())
+		if sig.Variadic() && i == len(args)-1 {
+			args[i] += "..."
+		}
+	}
+
+	call := fmt.Sprintf("%s(%s)", fun, strings.Join(args, ", "))
+
+	if sig.Results().Len() == 0 {
+		fmt.Fprintf(body, "\t%s\n", call)
+		return
+	}
+
+	results := make([]string, sig.Results().Len())
+	blanks := make([]string, len(results))
+	for i := range results {
+		results[i] = w.newVar(body, sig.Results().At(i).Type())
+		blanks[i] = "_"
+	}
+
+	fmt.Fprintf(body, "\t%s = %s\n", strings.Join(results, ", "), call)
+	fmt.Fprintf(body, "\t%s = %s\n", strings.Join(blanks, ", "), strings.Join(results, ", "))
+}
+
+// exerciseValue uses the value of the expression as one of the type.
+func (w *clientWriter) exerciseValue(body *bytes.Buffer, expr string, t types.Type) {
+	switch u := t.(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if f := u.Field(i); f.Exported() {
+				w.exerciseValue(body, expr+"."+f.Name(), f.Type())
+			}
+		}
+
+	case *types.Signature:
+		w.exerciseCall(body, expr, u)
+
+	case *types.Basic:
+		if u.Info()&types.IsUntyped != 0 {
+			fmt.Fprintf(body, "\t_ = %s\n", expr)
+			return
+		}
+		fmt.Fprintf(body, "\tvar _ %s = %s\n", w.typeString(t), expr)
+
+	default:
+		fmt.Fprintf(body, "\tvar _ %s = %s\n", w.typeString(t), expr)
+	}
+}
+
+// String returns the source of the client program.
+func (w *clientWriter) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "package %s\n\n", w.pkgName)
+	for _, path := range w.imports {
+		if alias := w.aliases[path]; alias != path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&buf, "import %s %s\n", alias, strconv.Quote(path))
+		} else {
+			fmt.Fprintf(&buf, "import %s\n", strconv.Quote(path))
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(w.buf.Bytes())
+
+	if src, err := format.Source(buf.Bytes()); err == nil {
+		return string(src)
+	}
+
+	return buf.String()
+}
+
+// check type-checks the client against the target package. Other imported packages
+// are looked up in the packages target depends on, and then in fallback and its dependencies.
+func (w *clientWriter) check(target, fallback *types.Package) error {
+	packages := map[string]*types.Package{}
+	if fallback != nil {
+		collectImports(fallback, packages)
+	}
+	collectImports(target, packages)
+	packages[w.target.Path()] = target
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client.go", w.String(), 0)
+	if err != nil {
+		return err
+	}
+
+	conf := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if pkg, ok := packages[path]; ok {
+				return pkg, nil
+			}
+			return nil, fmt.Errorf("package %s not found", path)
+		}),
+	}
+
+	_, err = conf.Check("client", fset, []*ast.File{file}, nil)
+	return err
+}
+
+// collectImports registers the package and its dependencies by their paths.
+func collectImports(pkg *types.Package, packages map[string]*types.Package) {
+	if _, ok := packages[pkg.Path()]; ok {
+		return
+	}
+
+	packages[pkg.Path()] = pkg
+	for _, imp := range pkg.Imports() {
+		collectImports(imp, packages)
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
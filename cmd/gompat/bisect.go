@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/motemen/gompatible"
+)
+
+func runBisect(args []string) {
+	flags := flag.NewFlagSet("bisect", flag.ExitOnError)
+	flagDiff := flags.Bool("d", false, "run diff on multi-line changes")
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) != 2 {
+		usage()
+	}
+
+	path, name, err := parseSymbol(args[1], packageExists)
+	dieIf(err)
+
+	repo, err := repoDir([]string{path})
+	dieIf(err)
+	dieIf(requireGit(repo, "bisect"))
+
+	good, bad, err := resolveRevisionRange(args[0], repo)
+	dieIf(err)
+
+	switch bad {
+	case "", gompatible.RevisionWorktree, gompatible.RevisionIndex:
+		dieIf(fmt.Errorf("bisect requires a range of commits: %q", args[0]))
+	}
+
+	commits, err := gitLog(repo, good, bad, "--first-parent")
+	dieIf(err)
+
+	if len(commits) == 0 {
+		dieIf(fmt.Errorf("no commits in %s..%s", good, bad))
+	}
+
+	b := &symbolBisect{
+		loader: &packageLoader{},
+		path:   path,
+		name:   name,
+	}
+
+	b.good, err = b.load(good)
+	dieIf(err)
+
+	_, err = b.change(b.good)
+	dieIf(err)
+
+	first, change, err := b.bisect(commits)
+	dieIf(err)
+
+	if first == nil {
+		fmt.Printf("%s is not broken at %s\n", args[1], bad)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s %s\n", first.ShortHash, first.Subject)
+	printChange(change, *flagDiff)
+}
+
+// parseSymbol splits "<import path>.<name>" into the import path and the name,
+// which may be "<type>.<method>". The import path defaults to the current directory.
+// As both the import path and the name may contain dots, eg. "gopkg.in/yaml.v2.Marshal",
+// the longest import path of a package found by exists is taken.
+func parseSymbol(s string, exists func(path string) bool) (string, string, error) {
+	for _, split := range symbolSplits(s) {
+		if exists(split[0]) {
+			return split[0], split[1], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%s: package not found", s)
+}
+
+// symbolSplits returns the possible pairs of the import path and the name of s, the longest path first.
+func symbolSplits(s string) [][2]string {
+	slash := strings.LastIndex(s, "/")
+
+	var splits [][2]string
+	for i := len(s) - 1; i > slash+1; i-- {
+		if s[i] != '.' {
+			continue
+		}
+
+		name := s[i+1:]
+		if name == "" || strings.Count(name, ".") > 1 {
+			break
+		}
+		splits = append(splits, [2]string{s[:i], name})
+	}
+
+	if slash == -1 && strings.Count(s, ".") <= 1 {
+		splits = append(splits, [2]string{".", s})
+	}
+
+	return splits
+}
+
+// packageExists reports whether the import path or the directory path is of a package, for parseSymbol.
+func packageExists(path string) bool {
+	_, err := gompatible.NewDirSpec(path, "", "")
+	return err == nil
+}
+
+// symbolBisect searches for the commit which broke the API name in the package at path.
+type symbolBisect struct {
+	loader *packageLoader
+	path   string
+	name   string
+	good   *gompatible.Package
+}
+
+// load loads only the package at the revision. It returns nil if there is none.
+func (b *symbolBisect) load(rev string) (*gompatible.Package, error) {
+	pkgs, err := b.loader.load(b.path, rev, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range pkgs {
+		return pkg, nil
+	}
+
+	return nil, nil
+}
+
+// change returns the change of the API between the good revision and pkg.
+func (b *symbolBisect) change(pkg *gompatible.Package) (gompatible.Change, error) {
+	c := findChange(gompatible.DiffPackages(b.good, pkg), b.name)
+	if c == nil {
+		return nil, fmt.Errorf("%s not found in %s", b.name, b.path)
+	}
+
+	return c, nil
+}
+
+// findChange returns the change of the API name, which may be "<type>.<method>",
+// or nil if there is none.
+func findChange(diff gompatible.PackageChanges, name string) gompatible.Change {
+	for _, changes := range diff.Changes {
+		if c, ok := changes[name]; ok {
+			return c
+		}
+	}
+
+	// The methods of an added or removed type are not listed
+	if dot := strings.Index(name, "."); dot != -1 {
+		if c, ok := diff.Changes[gompatible.ObjectCategoryType][name[:dot]]; ok {
+			switch c.Kind() {
+			case gompatible.ChangeAdded, gompatible.ChangeRemoved:
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// broken reports the change of the API at the revision if it is breaking or removed.
+func (b *symbolBisect) broken(rev string) (gompatible.Change, bool, error) {
+	pkg, err := b.load(rev)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c, err := b.change(pkg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch c.Kind() {
+	case gompatible.ChangeBreaking, gompatible.ChangeRemoved:
+		return c, true, nil
+	}
+
+	return c, false, nil
+}
+
+// bisect binary-searches the commits, oldest first, for the first one
+// where the API is broken. It returns nil if the last one is not broken.
+func (b *symbolBisect) bisect(commits []gitCommit) (*gitCommit, gompatible.Change, error) {
+	lo, hi := 0, len(commits)-1
+
+	change, ok, err := b.broken(commits[hi].Hash)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	for lo < hi {
+		fmt.Fprintf(os.Stderr, "bisecting: %d commits left\n", hi-lo)
+
+		mid := (lo + hi) / 2
+
+		c, ok, err := b.broken(commits[mid].Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if ok {
+			hi, change = mid, c
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return &commits[hi], change, nil
+}
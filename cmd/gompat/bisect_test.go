@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSymbol(t *testing.T) {
+	packages := map[string]bool{
+		".":                             true,
+		"./lib":                         true,
+		"fmt":                           true,
+		"gopkg.in/yaml.v2":              true,
+		"github.com/motemen/gompatible": true,
+	}
+	exists := func(path string) bool { return packages[path] }
+
+	tests := []struct {
+		symbol string
+		path   string
+		name   string
+		err    bool
+	}{
+		{"Foo", ".", "Foo", false},
+		{"T.M", ".", "T.M", false},
+		{"fmt.Println", "fmt", "Println", false},
+		{"./lib.T", "./lib", "T", false},
+		{"./lib.T.M", "./lib", "T.M", false},
+		{"gopkg.in/yaml.v2.Marshal", "gopkg.in/yaml.v2", "Marshal", false},
+		{"gopkg.in/yaml.v2.Decoder.Decode", "gopkg.in/yaml.v2", "Decoder.Decode", false},
+		{"github.com/motemen/gompatible.DiffPackages", "github.com/motemen/gompatible", "DiffPackages", false},
+		{"github.com/motemen/gompatible.FuncChange.Kind", "github.com/motemen/gompatible", "FuncChange.Kind", false},
+		{"example.com/missing.Foo", "", "", true},
+		{"a.b.c", "", "", true},
+	}
+
+	for _, test := range tests {
+		path, name, err := parseSymbol(test.symbol, exists)
+		if test.err {
+			assert.Error(t, err, test.symbol)
+			continue
+		}
+		if assert.NoError(t, err, test.symbol) {
+			assert.Equal(t, test.path, path, test.symbol)
+			assert.Equal(t, test.name, name, test.symbol)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/daviddengcn/go-colortext"
+)
+
+func runBlame(args []string) {
+	flags := flag.NewFlagSet("blame", flag.ExitOnError)
+	flagDiff := flags.Bool("d", false, "run diff on multi-line changes")
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		usage()
+	}
+
+	var revSpec string
+	if len(args) == 2 {
+		revSpec, args = args[0], args[1:]
+	}
+
+	path, name, err := parseSymbol(args[0], packageExists)
+	dieIf(err)
+
+	repo, err := repoDir([]string{path})
+	dieIf(err)
+	dieIf(requireGit(repo, "blame"))
+
+	rev1, rev2 := "", "HEAD"
+	if revSpec != "" {
+		rev1, rev2, err = resolveRevisionRange(revSpec, repo)
+		dieIf(err)
+	}
+
+	switch rev2 {
+	case "", gompatible.RevisionWorktree, gompatible.RevisionIndex:
+		dieIf(fmt.Errorf("blame requires a range of commits: %q", revSpec))
+	}
+
+	// Only the commits touching the package directory may change the API
+	commits, err := gitLog(repo, rev1, rev2, "--no-merges", "--", ".")
+	dieIf(err)
+
+	b := newSymbolBlame(&packageLoader{}, path, name)
+
+	var shown bool
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+
+		change, notes := b.change(c)
+		if change == nil {
+			continue
+		}
+
+		if shown {
+			fmt.Println()
+		}
+		shown = true
+
+		ct.ChangeColor(ct.Yellow, false, ct.None, false)
+		fmt.Print(c.ShortHash)
+		ct.ResetColor()
+		fmt.Printf(" %s %s %s\n", c.Date, c.Author, c.Subject)
+
+		printChange(change, *flagDiff)
+		for _, note := range notes {
+			fmt.Printf("  (%s)\n", note)
+		}
+	}
+
+	if !shown {
+		dieIf(fmt.Errorf("%s not found in the history", args[0]))
+	}
+}
+
+// blameCacheSize is the number of packages symbolBlame keeps. As commits are
+// walked parents first, most parents have just been loaded as children.
+const blameCacheSize = 4
+
+// symbolBlame finds the commits which changed the API name in the package at path.
+type symbolBlame struct {
+	loader *packageLoader
+	path   string
+	name   string
+	// loaded caches the packages by the commit hashes, the oldest first in order
+	loaded map[string]*gompatible.Package
+	order  []string
+}
+
+func newSymbolBlame(loader *packageLoader, path, name string) *symbolBlame {
+	return &symbolBlame{
+		loader: loader,
+		path:   path,
+		name:   name,
+		loaded: map[string]*gompatible.Package{},
+	}
+}
+
+// load loads only the package at the revision. It returns nil if there is none
+// or it fails to load.
+func (b *symbolBlame) load(rev string) *gompatible.Package {
+	if pkg, ok := b.loaded[rev]; ok {
+		return pkg
+	}
+
+	var pkg *gompatible.Package
+
+	pkgs, err := b.loader.load(b.path, rev, false)
+	if err != nil {
+		warnf("%s: %s", rev, err)
+	}
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	if len(b.order) == blameCacheSize {
+		delete(b.loaded, b.order[0])
+		b.order = b.order[1:]
+	}
+	b.loaded[rev] = pkg
+	b.order = append(b.order, rev)
+
+	return pkg
+}
+
+// change returns the change of the API made by the commit against its first parent,
+// with notes on changes of the classification, or nil if the commit did not change it.
+func (b *symbolBlame) change(c gitCommit) (gompatible.Change, []string) {
+	var pkg1 *gompatible.Package
+	if len(c.Parents) > 0 {
+		pkg1 = b.load(c.Parents[0])
+	}
+	pkg2 := b.load(c.Hash)
+
+	change := findChange(gompatible.DiffPackages(pkg1, pkg2), b.name)
+	if change == nil {
+		return nil, nil
+	}
+
+	var notes []string
+
+	switch change.Kind() {
+	case gompatible.ChangeAdded, gompatible.ChangeRemoved:
+		return change, nil
+	}
+
+	if before, after := gompatible.Deprecation(change); before != after {
+		if after {
+			notes = append(notes, "deprecated")
+		} else {
+			notes = append(notes, "no longer deprecated")
+		}
+	}
+
+	before, after := gompatible.Directives(change)
+	if s1, s2 := directivesString(before), directivesString(after); s1 != s2 {
+		notes = append(notes, fmt.Sprintf("directives changed: %s -> %s", s1, s2))
+	}
+
+	// eg. renamed parameters, which keep the API but may matter to readers
+	if change.Kind() == gompatible.ChangeUnchanged && change.ShowBefore() != change.ShowAfter() {
+		notes = append(notes, "declaration edited without changing the API")
+	}
+
+	if change.Kind() == gompatible.ChangeUnchanged && len(notes) == 0 {
+		return nil, nil
+	}
+
+	return change, notes
+}
+
+func directivesString(directives []gompatible.Directive) string {
+	if len(directives) == 0 {
+		return "none"
+	}
+
+	names := make([]string, len(directives))
+	for i, d := range directives {
+		names[i] = "//gompat:" + string(d)
+	}
+
+	return strings.Join(names, " ")
+}
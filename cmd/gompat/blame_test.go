@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolBlame(t *testing.T) {
+	repo, git := gitRepo(t)
+	defer os.RemoveAll(repo)
+
+	for _, c := range []struct{ subject, source string }{
+		{"add F", "package lib\n\nfunc F(a int) {}\n"},
+		{"add G", "package lib\n\nfunc F(a int) {}\n\nfunc G() {}\n"},
+		{"rename parameter", "package lib\n\nfunc F(b int) {}\n\nfunc G() {}\n"},
+		{"edit body", "package lib\n\nfunc F(b int) { println(b) }\n\nfunc G() {}\n"},
+		{"add parameter", "package lib\n\nfunc F(b int, c string) {}\n\nfunc G() {}\n"},
+		{"deprecate F", "package lib\n\n// Deprecated: use G.\nfunc F(b int, c string) {}\n\nfunc G() {}\n"},
+		{"remove F", "package lib\n\nfunc G() {}\n"},
+	} {
+		writeFiles(t, repo, map[string]string{"lib.go": c.source})
+		git("add", ".")
+		git("commit", "-q", "-m", c.subject)
+	}
+
+	commits, err := gitLog(repo, "", "HEAD", "--no-merges", "--", ".")
+	require.NoError(t, err)
+	require.Len(t, commits, 7)
+
+	type blamed struct {
+		kind  gompatible.ChangeKind
+		notes []string
+	}
+
+	b := newSymbolBlame(&packageLoader{}, repo, "F")
+	got := map[string]blamed{}
+	for _, c := range commits {
+		if change, notes := b.change(c); change != nil {
+			got[c.Subject] = blamed{change.Kind(), notes}
+		}
+		assert.True(t, len(b.loaded) <= blameCacheSize, "cache of %d packages", len(b.loaded))
+	}
+
+	assert.Equal(t, map[string]blamed{
+		"add F":            {gompatible.ChangeAdded, nil},
+		"rename parameter": {gompatible.ChangeUnchanged, []string{"declaration edited without changing the API"}},
+		"add parameter":    {gompatible.ChangeBreaking, nil},
+		"deprecate F":      {gompatible.ChangeUnchanged, []string{"deprecated"}},
+		"remove F":         {gompatible.ChangeRemoved, nil},
+	}, got)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/motemen/gompatible"
+)
+
+// changelogGroups are the headings of the changelog, in the order of appearance.
+var changelogGroups = []string{"Breaking", "Removed", "Added", "Deprecated", "Compatible"}
+
+// changelogGroup returns the heading which the change should be listed under,
+// or an empty string if it should not be listed.
+func changelogGroup(c gompatible.Change) string {
+	switch c.Kind() {
+	case gompatible.ChangeBreaking:
+		return "Breaking"
+	case gompatible.ChangeRemoved:
+		return "Removed"
+	case gompatible.ChangeAdded:
+		return "Added"
+	}
+
+	if before, after := gompatible.Deprecation(c); !before && after {
+		return "Deprecated"
+	}
+
+	if c.Kind() == gompatible.ChangeCompatible {
+		return "Compatible"
+	}
+
+	return ""
+}
+
+func runChangelog(args []string) {
+	flags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	flagRecurse := flags.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) < 1 {
+		usage()
+	}
+
+	conf, err := loadProjectConfig("")
+	dieIf(err)
+
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = conf.packages()
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	repo, err := repoDir(paths)
+	dieIf(err)
+	dieIf(requireGit(repo, "changelog"))
+
+	rev1, rev2, err := resolveRevisionRange(args[0], repo)
+	dieIf(err)
+
+	tags, err := semverTags(repo)
+	dieIf(err)
+
+	loader := &packageLoader{
+		VCS:     "",
+		Recurse: *flagRecurse,
+		Exclude: conf.Exclude,
+	}
+
+	ranges := changelogRanges(tags, rev1, rev2)
+	for i := len(ranges) - 1; i >= 0; i-- {
+		r := ranges[i]
+
+		diffs, err := loader.diff(paths, r[0], r[1])
+		dieIf(err)
+
+		if i < len(ranges)-1 {
+			fmt.Println()
+		}
+		entries := listChanges(diffs)
+		addBreakingExamples(entries, diffs)
+		printChangelog(os.Stdout, r[0], r[1], entries)
+	}
+}
+
+// changelogRanges splits the revision range into ranges of consecutive tags
+// if both ends of the range are tags, so that each release has its own section.
+func changelogRanges(tags []string, rev1, rev2 string) [][2]string {
+	i, j := -1, -1
+	for k, tag := range tags {
+		if tag == rev1 {
+			i = k
+		}
+		if tag == rev2 {
+			j = k
+		}
+	}
+
+	if i == -1 || j == -1 || i >= j {
+		return [][2]string{{rev1, rev2}}
+	}
+
+	ranges := make([][2]string, 0, j-i)
+	for k := i; k < j; k++ {
+		ranges = append(ranges, [2]string{tags[k], tags[k+1]})
+	}
+
+	return ranges
+}
+
+func printChangelog(w io.Writer, rev1, rev2 string, entries []changeEntry) {
+	title := rev2
+	if title == "" {
+		title = "Unreleased"
+	}
+
+	fmt.Fprintf(w, "## %s\n\n", title)
+	fmt.Fprintf(w, "API changes since %s.\n", rev1)
+
+	groups := map[string]map[string][]changeEntry{}
+	packages := []string{}
+	for _, e := range entries {
+		g := changelogGroup(e.Change)
+		if g == "" {
+			continue
+		}
+
+		if groups[e.Package] == nil {
+			groups[e.Package] = map[string][]changeEntry{}
+			packages = append(packages, e.Package)
+		}
+		groups[e.Package][g] = append(groups[e.Package][g], e)
+	}
+
+	if len(packages) == 0 {
+		fmt.Fprintf(w, "\nNo API changes.\n")
+		return
+	}
+
+	for _, pkg := range packages {
+		fmt.Fprintf(w, "\n### %s\n", pkg)
+
+		for _, g := range changelogGroups {
+			if len(groups[pkg][g]) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(w, "\n#### %s\n", g)
+			for _, e := range groups[pkg][g] {
+				fmt.Fprintf(w, "\n- `%s`\n\n", e.Name)
+				printChangelogSignatures(w, e.Change)
+				if e.Example != "" && (g == "Breaking" || g == "Removed") {
+					fmt.Fprintf(w, "\n  Code like this no longer compiles:\n\n")
+					printCodeBlock(w, strings.TrimRight(e.Example, "\n"))
+				}
+			}
+		}
+	}
+}
+
+func printChangelogSignatures(w io.Writer, c gompatible.Change) {
+	var lines []string
+
+	switch c.Kind() {
+	case gompatible.ChangeAdded:
+		lines = []string{c.ShowAfter()}
+	case gompatible.ChangeRemoved:
+		lines = []string{c.ShowBefore()}
+	case gompatible.ChangeBreaking, gompatible.ChangeCompatible:
+		lines = []string{"// Before", c.ShowBefore(), "", "// After", c.ShowAfter()}
+	default:
+		// Deprecated
+		lines = []string{c.ShowAfter()}
+	}
+
+	printCodeBlock(w, strings.Join(lines, "\n"))
+}
+
+// printCodeBlock prints the Go code as a fenced code block in a list item.
+func printCodeBlock(w io.Writer, code string) {
+	fmt.Fprintln(w, "  ```go")
+	for _, line := range strings.Split(code, "\n") {
+		if line == "" {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprintln(w, "  "+line)
+		}
+	}
+	fmt.Fprintln(w, "  ```")
+}
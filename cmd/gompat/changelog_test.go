@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangelogRanges(t *testing.T) {
+	tags := []string{"v1.0.0", "v1.1.0", "v1.2.0", "v2.0.0"}
+
+	tests := []struct {
+		rev1, rev2 string
+		ranges     [][2]string
+	}{
+		{"v1.0.0", "v1.1.0", [][2]string{{"v1.0.0", "v1.1.0"}}},
+		{"v1.0.0", "v2.0.0", [][2]string{{"v1.0.0", "v1.1.0"}, {"v1.1.0", "v1.2.0"}, {"v1.2.0", "v2.0.0"}}},
+		{"v1.1.0", "v1.2.0", [][2]string{{"v1.1.0", "v1.2.0"}}},
+		// Not both ends are tags
+		{"v1.2.0", "HEAD", [][2]string{{"v1.2.0", "HEAD"}}},
+		{"v1.2.0", "", [][2]string{{"v1.2.0", ""}}},
+		{"abc123", "v2.0.0", [][2]string{{"abc123", "v2.0.0"}}},
+		// Reversed
+		{"v2.0.0", "v1.0.0", [][2]string{{"v2.0.0", "v1.0.0"}}},
+		// Empty
+		{"v1.1.0", "v1.1.0", [][2]string{{"v1.1.0", "v1.1.0"}}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.ranges, changelogRanges(tags, test.rev1, test.rev2), "%s..%s", test.rev1, test.rev2)
+	}
+
+	// No tags in the repository
+	assert.Equal(t, [][2]string{{"v1.0.0", "v1.1.0"}}, changelogRanges(nil, "v1.0.0", "v1.1.0"))
+}
+
+func TestPrintChangelog(t *testing.T) {
+	all := testdataEntries(t)
+
+	removed := all["Removed1"]
+	removed.Example = "func _() {\n\ttestdata.Removed1()\n}\n"
+
+	tests := []struct {
+		rev1, rev2 string
+		entries    []changeEntry
+		expected   string
+	}{
+		{"v1.0.0", "v1.1.0", []changeEntry{all["Unchanged1"]}, `## v1.1.0
+
+API changes since v1.0.0.
+
+No API changes.
+`},
+		{"v1.0.0", "", []changeEntry{all["Compatible1"], all["Added1"], removed, all["Breaking1"], all["Unchanged1"]}, `## Unreleased
+
+API changes since v1.0.0.
+
+### testdata
+
+#### Breaking
+
+- 'Breaking1'
+
+  ~~~go
+  // Before
+  func Breaking1(n int)
+
+  // After
+  func Breaking1(n int, b bool)
+  ~~~
+
+#### Removed
+
+- 'Removed1'
+
+  ~~~go
+  func Removed1()
+  ~~~
+
+  Code like this no longer compiles:
+
+  ~~~go
+  func _() {
+  	testdata.Removed1()
+  }
+  ~~~
+
+#### Added
+
+- 'Added1'
+
+  ~~~go
+  func Added1()
+  ~~~
+
+#### Compatible
+
+- 'Compatible1'
+
+  ~~~go
+  // Before
+  func Compatible1(n int)
+
+  // After
+  func Compatible1(n int, opts ...string)
+  ~~~
+`},
+	}
+
+	// Backquotes and fences cannot be in raw strings
+	markdown := strings.NewReplacer("'", "`", "~~~", "```")
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		printChangelog(&buf, test.rev1, test.rev2, test.entries)
+		assert.Equal(t, markdown.Replace(test.expected), buf.String())
+	}
+}
+
+func TestPrintChangelogGroups(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go":     {Data: []byte("package lib\n\nfunc F() {}\n\nfunc G() {}\n")},
+		"v1/sub/sub.go": {Data: []byte("package sub\n\nfunc S() {}\n")},
+		"v2/lib.go":     {Data: []byte("package lib\n\n// Deprecated: use G.\nfunc F() {}\n\nfunc G() {}\n\nfunc H() {}\n")},
+		"v2/sub/sub.go": {Data: []byte("package sub\n\nfunc S(s string) {}\n")},
+	}
+
+	pkgs1, err := gompatible.LoadFS(lib, "v1", "example.com/lib", true)
+	require.NoError(t, err)
+	pkgs2, err := gompatible.LoadFS(lib, "v2", "example.com/lib", true)
+	require.NoError(t, err)
+
+	// Examples are shown only for breaking changes and removals
+	entries := listChanges(diffPackageSets(pkgs1, pkgs2))
+	for i := range entries {
+		entries[i].Example = "func _() {}\n"
+	}
+
+	var buf bytes.Buffer
+	printChangelog(&buf, "v1", "v2", entries)
+	assert.Equal(t, strings.NewReplacer("'", "`", "~~~", "```").Replace(`## v2
+
+API changes since v1.
+
+### example.com/lib
+
+#### Added
+
+- 'H'
+
+  ~~~go
+  func H()
+  ~~~
+
+#### Deprecated
+
+- 'F'
+
+  ~~~go
+  func F()
+  ~~~
+
+### example.com/lib/sub
+
+#### Breaking
+
+- 'S'
+
+  ~~~go
+  // Before
+  func S()
+
+  // After
+  func S(s string)
+  ~~~
+
+  Code like this no longer compiles:
+
+  ~~~go
+  func _() {}
+  ~~~
+`), buf.String())
+}
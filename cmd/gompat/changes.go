@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// changeEntry is an API change along with the package and the name of it.
+type changeEntry struct {
+	Package  string
+	Name     string
+	Category gompatible.ObjectCategory
+	Change   gompatible.Change
+
+	// Acknowledged is the suppression matched the change, if any
+	Acknowledged *suppression
+
+	// Example is the client code broken by the change, if computed by addBreakingExamples
+	Example string
+
+	// Corpus is how widely the API is used in the module cache, if computed by addCorpusUsages
+	Corpus *gompatible.CorpusUsage
+}
+
+var objectCategories = []gompatible.ObjectCategory{
+	gompatible.ObjectCategoryFunc,
+	gompatible.ObjectCategoryType,
+	gompatible.ObjectCategoryValue,
+}
+
+// listChanges flattens package changes into a list ordered by package path,
+// category and name.
+func listChanges(diffs map[string]gompatible.PackageChanges) []changeEntry {
+	entries := []changeEntry{}
+
+	for _, pkgName := range util.SortedStringSet(util.MapKeys(diffs)) {
+		diff := diffs[pkgName]
+		for _, cat := range objectCategories {
+			changes := diff.Changes[cat]
+			for _, name := range util.SortedStringSet(util.MapKeys(changes)) {
+				entries = append(entries, changeEntry{
+					Package:  pkgName,
+					Name:     name,
+					Category: cat,
+					Change:   changes[name],
+				})
+			}
+		}
+	}
+
+	return entries
+}
+
+// addBreakingExamples fills the examples of client code broken by breaking changes and removals.
+func addBreakingExamples(entries []changeEntry, diffs map[string]gompatible.PackageChanges) {
+	for i, e := range entries {
+		entries[i].Example = gompatible.BreakingExample(diffs[e.Package], e.Name)
+	}
+}
+
+// addCorpusUsages fills how widely the package-level APIs existing before the changes
+// are used in the modules in the module cache modCache.
+func addCorpusUsages(entries []changeEntry, diffs map[string]gompatible.PackageChanges, modCache string) error {
+	scanned := map[string]map[string]*gompatible.CorpusUsage{}
+
+	for i, e := range entries {
+		pc := diffs[e.Package]
+		if pc.Before == nil || e.Change.Kind() == gompatible.ChangeAdded || strings.Contains(e.Name, ".") {
+			continue
+		}
+
+		usages, ok := scanned[e.Package]
+		if !ok {
+			var err error
+			usages, err = gompatible.ScanCorpus(modCache, importPathOf(e.Package), pc.Before.TypesPkg.Name())
+			if err != nil {
+				return err
+			}
+			scanned[e.Package] = usages
+		}
+
+		if u := usages[e.Name]; u != nil {
+			entries[i].Corpus = u
+		} else {
+			entries[i].Corpus = &gompatible.CorpusUsage{}
+		}
+	}
+
+	return nil
+}
+
+func filterChanges(entries []changeEntry, pred func(changeEntry) bool) []changeEntry {
+	filtered := make([]changeEntry, 0, len(entries))
+	for _, e := range entries {
+		if pred(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// changeKinds maps names of change kinds used in flags and configuration to ChangeKinds.
+var changeKinds = map[string]gompatible.ChangeKind{
+	"unchanged":  gompatible.ChangeUnchanged,
+	"added":      gompatible.ChangeAdded,
+	"removed":    gompatible.ChangeRemoved,
+	"compatible": gompatible.ChangeCompatible,
+	"breaking":   gompatible.ChangeBreaking,
+}
+
+func parseKinds(names []string) ([]gompatible.ChangeKind, error) {
+	kinds := make([]gompatible.ChangeKind, 0, len(names))
+	for _, name := range names {
+		kind, ok := changeKinds[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown kind: %q", name)
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type checkstyleOutput struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func printCheckstyle(w io.Writer, entries []changeEntry) error {
+	out := checkstyleOutput{Version: "4.3"}
+
+	fileIndex := map[string]int{}
+	for _, is := range listIssues(entries) {
+		i, ok := fileIndex[is.Pos.Filename]
+		if !ok {
+			i = len(out.Files)
+			fileIndex[is.Pos.Filename] = i
+			out.Files = append(out.Files, checkstyleFile{Name: is.Pos.Filename})
+		}
+
+		severity, message := "error", is.Message
+		if is.Acknowledged != nil {
+			severity, message = "info", message+" ("+acknowledgedNote(is.Acknowledged)+")"
+		}
+
+		out.Files[i].Errors = append(out.Files[i].Errors, checkstyleError{
+			Line:     is.Pos.Line,
+			Column:   is.Pos.Column,
+			Severity: severity,
+			Message:  message,
+			Source:   "gompat." + is.Rule,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintCheckstyle(t *testing.T) {
+	all := testdataEntries(t)
+
+	acked := all["Removed1"]
+	acked.Acknowledged = &suppression{Symbol: "Removed1", Justification: "unused"}
+
+	entries := []changeEntry{all["Added1"], all["Breaking1"], acked, all["BreakingT1"], all["Compatible1"]}
+
+	// Breaking changes are located in the after tree and removals in the before tree,
+	// grouped by the files
+	var buf bytes.Buffer
+	require.NoError(t, printCheckstyle(&buf, entries))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="after/t.go">
+    <error line="15" column="6" severity="error" message="Breaking change of func Breaking1: parameter #2 added: bool" source="gompat.breaking"></error>
+    <error line="38" column="6" severity="error" message="Breaking change of type BreakingT1: field XXX removed; field YYY added" source="gompat.breaking"></error>
+  </file>
+  <file name="before/t.go">
+    <error line="19" column="6" severity="info" message="Removal of func Removed1 (acknowledged: unused)" source="gompat.removed"></error>
+  </file>
+</checkstyle>
+`, buf.String())
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configFileName is the name of the configuration file searched by default.
+const configFileName = ".gompat.yaml"
+
+// config is the content of the configuration file.
+type config struct {
+	// Packages are the import paths to inspect when none is given by arguments.
+	// Relative paths are relative to the directory of the configuration file.
+	Packages []string `yaml:"packages"`
+	// Exclude is the list of glob patterns of directories and files to skip in recursive mode.
+	Exclude []string `yaml:"exclude"`
+
+	// Defaults of the flags
+	Format   string `yaml:"format"`
+	Template string `yaml:"template"`
+	All      bool   `yaml:"all"`
+	Diff     bool   `yaml:"diff"`
+	Verbose  bool   `yaml:"verbose"`
+
+	// FailOn is the list of kinds of changes which make gompat exit with failure.
+	// An empty list means none.
+	FailOn       []string `yaml:"fail_on"`
+	SeverityExit bool     `yaml:"severity_exit"`
+
+	Suppressions []*suppression `yaml:"suppressions"`
+
+	// the directory of the configuration file
+	dir string
+}
+
+// findConfigFile searches the configuration file from dir up to the root.
+// It returns an empty string if not found.
+func findConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		file := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadProjectConfig loads the configuration file. If file is empty, the file is searched
+// from the working directory, and an empty configuration is returned if not found.
+func loadProjectConfig(file string) (*config, error) {
+	if file == "" {
+		var err error
+		file, err = findConfigFile(".")
+		if err != nil {
+			return nil, err
+		}
+		if file == "" {
+			return &config{}, nil
+		}
+	}
+
+	return loadConfig(file)
+}
+
+func loadConfig(file string) (*config, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf config
+	if err := yaml.UnmarshalStrict(b, &conf); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	conf.dir, err = filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range conf.Suppressions {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+	}
+
+	if _, err := parseFailOn(conf.FailOn); err != nil {
+		return nil, fmt.Errorf("%s: fail_on: %s", file, err)
+	}
+
+	return &conf, nil
+}
+
+// packages returns the default import paths, relative ones resolved against the directory of the file.
+func (c *config) packages() []string {
+	paths := make([]string, len(c.Packages))
+	for i, path := range c.Packages {
+		if path == "." || strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+			p, recurse := parsePathArg(path)
+			path = filepath.Join(c.dir, p)
+			if recurse {
+				path += "/..."
+			}
+		}
+		paths[i] = path
+	}
+	return paths
+}
+
+// isFlagSet reports whether the flag is given explicitly or set by the configuration.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// applyFlagDefaults sets the flags by the configuration unless they are explicitly given.
+func (c *config) applyFlagDefaults(flags *flag.FlagSet) error {
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	defaults := map[string]string{}
+	if c.Format != "" {
+		defaults["format"] = c.Format
+	}
+	if c.Template != "" && given["format"] == false {
+		defaults["template"] = c.Template
+		if filepath.IsAbs(c.Template) == false {
+			defaults["template"] = filepath.Join(c.dir, c.Template)
+		}
+	}
+	if c.All {
+		defaults["a"] = strconv.FormatBool(c.All)
+	}
+	if c.Diff {
+		defaults["d"] = strconv.FormatBool(c.Diff)
+	}
+	if c.Verbose {
+		defaults["v"] = strconv.FormatBool(c.Verbose)
+	}
+	if c.FailOn != nil {
+		defaults["fail-on"] = failOnNone
+		if len(c.FailOn) > 0 {
+			defaults["fail-on"] = strings.Join(c.FailOn, ",")
+		}
+	}
+	if c.SeverityExit {
+		defaults["severity-exit"] = strconv.FormatBool(c.SeverityExit)
+	}
+
+	for name, value := range defaults {
+		if given[name] || flags.Lookup(name) == nil {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	for _, dir := range []string{"a/b/c", "a/x", "y"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, dir), 0755))
+	}
+	for _, dir := range []string{"a", "a/b/c"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, dir, configFileName), nil, 0644))
+	}
+
+	tests := []struct {
+		dir  string
+		file string
+	}{
+		{"a", "a/" + configFileName},
+		{"a/x", "a/" + configFileName},
+		{"a/b", "a/" + configFileName},
+		// The nearest one is taken
+		{"a/b/c", "a/b/c/" + configFileName},
+		{"y", ""},
+	}
+
+	for _, test := range tests {
+		file, err := findConfigFile(filepath.Join(tempDir, test.dir))
+		require.NoError(t, err)
+
+		if test.file == "" {
+			// There may be one above the temporary directory
+			assert.False(t, strings.HasPrefix(file, tempDir), test.dir)
+			continue
+		}
+		assert.Equal(t, filepath.Join(tempDir, test.file), file, test.dir)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		content string
+		err     bool
+	}{
+		{"packages: [., ./sub/..., example.com/foo]\nfail_on: [Breaking, removed]\n", false},
+		{"suppressions:\n  - symbol: F\n    kind: removed\n    expires: 2024-01-31\n", false},
+		{"unknown: true\n", true},
+		{"fail_on: [bogus]\n", true},
+		{"suppressions:\n  - kind: removed\n", true},
+		{"suppressions:\n  - symbol: F\n    expires: tomorrow\n", true},
+	}
+
+	for i, test := range tests {
+		file := filepath.Join(tempDir, configFileName)
+		require.NoError(t, ioutil.WriteFile(file, []byte(test.content), 0644))
+
+		conf, err := loadConfig(file)
+		if test.err {
+			assert.Error(t, err, "#%d", i)
+			continue
+		}
+		if assert.NoError(t, err, "#%d", i) {
+			assert.Equal(t, tempDir, conf.dir)
+		}
+	}
+}
+
+func TestConfigPackages(t *testing.T) {
+	conf := &config{
+		Packages: []string{".", "./sub", "./sub/...", "../other", "example.com/foo/..."},
+		dir:      "/project",
+	}
+
+	assert.Equal(t, []string{
+		filepath.Join("/project"),
+		filepath.Join("/project", "sub"),
+		filepath.Join("/project", "sub") + "/...",
+		filepath.Join("/other"),
+		"example.com/foo/...",
+	}, conf.packages())
+}
+
+func TestApplyFlagDefaults(t *testing.T) {
+	tests := []struct {
+		args     []string
+		conf     config
+		format   string
+		failOn   string
+		severity bool
+	}{
+		{nil, config{}, "text", "breaking", false},
+		{nil, config{Format: "json", FailOn: []string{"added"}, SeverityExit: true}, "json", "added", true},
+		// An empty list disables failure
+		{nil, config{FailOn: []string{}}, "text", "none", false},
+		// Flags given explicitly take precedence
+		{[]string{"-format=sarif", "-fail-on=removed"}, config{Format: "json", FailOn: []string{"added"}}, "sarif", "removed", false},
+	}
+
+	for i, test := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		format := flags.String("format", "text", "")
+		failOn := flags.String("fail-on", "breaking", "")
+		severity := flags.Bool("severity-exit", false, "")
+		require.NoError(t, flags.Parse(test.args))
+
+		require.NoError(t, test.conf.applyFlagDefaults(flags))
+		assert.Equal(t, test.format, *format, "#%d", i)
+		assert.Equal(t, test.failOn, *failOn, "#%d", i)
+		assert.Equal(t, test.severity, *severity, "#%d", i)
+		assert.Equal(t, test.conf.FailOn != nil || len(test.args) > 0, isFlagSet(flags, "fail-on"), "#%d", i)
+	}
+}
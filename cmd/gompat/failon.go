@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/motemen/gompatible"
+)
+
+// Names of the rules for -fail-on, in addition to the names of change kinds.
+const (
+	// failOnRemovedDeprecated is for removals of the APIs which were deprecated,
+	// which "removed" does not cover.
+	failOnRemovedDeprecated = "removed-deprecated"
+	// failOnNone disables failure.
+	failOnNone = "none"
+)
+
+var defaultFailOn = []string{"breaking", "removed", failOnRemovedDeprecated}
+
+// severityFailOn is the default of -fail-on with -severity-exit, which fails also
+// on the changes needing a minor version bump so that exitMinor can be returned.
+var severityFailOn = []string{"breaking", "removed", failOnRemovedDeprecated, "added", "compatible"}
+
+// Exit statuses for -severity-exit. 1 is reserved for errors.
+const (
+	exitMajor = 3
+	exitMinor = 2
+)
+
+// parseFailOn parses the names of -fail-on into the set of them, which are case-insensitive.
+func parseFailOn(names []string) (map[string]bool, error) {
+	failOn := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case failOnNone, "":
+			continue
+		case failOnRemovedDeprecated:
+			failOn[name] = true
+		default:
+			if _, err := parseKinds([]string{name}); err != nil {
+				return nil, fmt.Errorf("-fail-on: %s", err)
+			}
+			failOn[name] = true
+		}
+	}
+
+	return failOn, nil
+}
+
+// failRule returns the name of the -fail-on rule which the entry falls in.
+func failRule(e changeEntry) string {
+	if e.Change.Kind() == gompatible.ChangeRemoved {
+		if deprecated, _ := gompatible.Deprecation(e.Change); deprecated {
+			return failOnRemovedDeprecated
+		}
+	}
+
+	for name, kind := range changeKinds {
+		if e.Change.Kind() == kind {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// exitStatus determines the exit status of gompat by the changes not acknowledged.
+// If bySeverity is true, the status tells the version bump needed: exitMajor for
+// incompatible changes and exitMinor for the others. Otherwise it is 1 for any failure.
+func exitStatus(entries []changeEntry, failOn map[string]bool, bySeverity bool) int {
+	status := 0
+
+	for _, e := range entries {
+		if e.Acknowledged != nil || failOn[failRule(e)] == false {
+			continue
+		}
+
+		switch e.Change.Kind() {
+		case gompatible.ChangeBreaking, gompatible.ChangeRemoved:
+			status = exitMajor
+		default:
+			if status < exitMinor {
+				status = exitMinor
+			}
+		}
+	}
+
+	if status != 0 && bySeverity == false {
+		return 1
+	}
+
+	return status
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFailOn(t *testing.T) {
+	tests := []struct {
+		names  []string
+		failOn map[string]bool
+		err    bool
+	}{
+		{defaultFailOn, map[string]bool{"breaking": true, "removed": true, "removed-deprecated": true}, false},
+		{[]string{"Breaking", " ADDED"}, map[string]bool{"breaking": true, "added": true}, false},
+		{[]string{"Removed-Deprecated"}, map[string]bool{"removed-deprecated": true}, false},
+		{[]string{"none"}, map[string]bool{}, false},
+		{[]string{""}, map[string]bool{}, false},
+		{[]string{"breaking", "bogus"}, nil, true},
+	}
+
+	for _, test := range tests {
+		failOn, err := parseFailOn(test.names)
+		if test.err {
+			assert.Error(t, err, "%v", test.names)
+			continue
+		}
+		require.NoError(t, err, "%v", test.names)
+		assert.Equal(t, test.failOn, failOn, "%v", test.names)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	all := testdataEntries(t)
+	pick := func(names ...string) []changeEntry {
+		entries := make([]changeEntry, len(names))
+		for i, name := range names {
+			entries[i] = all[name]
+		}
+		return entries
+	}
+
+	acked := all["Breaking1"]
+	acked.Acknowledged = &suppression{}
+
+	tests := []struct {
+		entries    []changeEntry
+		failOn     []string
+		bySeverity bool
+		status     int
+	}{
+		{pick("Unchanged1", "Added1", "Compatible1"), defaultFailOn, false, 0},
+		{pick("Added1", "Breaking1"), defaultFailOn, false, 1},
+		{pick("Removed1"), []string{"Breaking"}, false, 0},
+		{pick("Breaking1"), []string{"Breaking"}, false, 1},
+		{[]changeEntry{acked}, defaultFailOn, false, 0},
+		{pick("Added1", "Breaking1"), defaultFailOn, true, exitMajor},
+		{pick("Added1", "Compatible1"), defaultFailOn, true, 0},
+		{pick("Added1", "Compatible1"), severityFailOn, true, exitMinor},
+		{pick("Compatible1", "Removed1"), severityFailOn, true, exitMajor},
+		{pick("Unchanged1"), severityFailOn, true, 0},
+		{pick("Added1", "Breaking1"), []string{"none"}, true, 0},
+	}
+
+	for i, test := range tests {
+		failOn, err := parseFailOn(test.failOn)
+		require.NoError(t, err)
+		assert.Equal(t, test.status, exitStatus(test.entries, failOn, test.bySeverity), "#%d", i)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/motemen/gompatible"
+)
+
+// changeFilter selects changes to report by their kinds, categories and names.
+type changeFilter struct {
+	// nil for any
+	kinds      map[gompatible.ChangeKind]bool
+	categories map[gompatible.ObjectCategory]bool
+	match      *regexp.Regexp
+	exclude    *regexp.Regexp
+}
+
+// newChangeFilter builds a changeFilter from comma-separated kinds and categories
+// and regular expressions of names, each of which may be empty.
+func newChangeFilter(kinds, categories, match, exclude string) (*changeFilter, error) {
+	f := &changeFilter{}
+
+	if kinds != "" {
+		ks, err := parseKinds(strings.Split(kinds, ","))
+		if err != nil {
+			return nil, err
+		}
+
+		f.kinds = map[gompatible.ChangeKind]bool{}
+		for _, k := range ks {
+			f.kinds[k] = true
+		}
+	}
+
+	if categories != "" {
+		f.categories = map[gompatible.ObjectCategory]bool{}
+		for _, name := range strings.Split(categories, ",") {
+			cat := gompatible.ObjectCategory(strings.ToLower(strings.TrimSpace(name)))
+			switch cat {
+			case gompatible.ObjectCategoryFunc, gompatible.ObjectCategoryType, gompatible.ObjectCategoryValue:
+				f.categories[cat] = true
+			default:
+				return nil, fmt.Errorf("unknown category: %q", name)
+			}
+		}
+	}
+
+	var err error
+	if match != "" {
+		if f.match, err = regexp.Compile(match); err != nil {
+			return nil, err
+		}
+	}
+	if exclude != "" {
+		if f.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// accepts reports whether the change should be reported.
+// Names are matched in the form of "<package>.<name>".
+func (f *changeFilter) accepts(e changeEntry) bool {
+	if f.kinds != nil && f.kinds[e.Change.Kind()] == false {
+		return false
+	}
+
+	if f.categories != nil && f.categories[e.Category] == false {
+		return false
+	}
+
+	name := e.Package + "." + e.Name
+	if f.match != nil && f.match.MatchString(name) == false {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+
+	return true
+}
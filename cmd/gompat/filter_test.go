@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/motemen/gompatible/internal/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeFilter(t *testing.T) {
+	all := testdataEntries(t)
+
+	tests := []struct {
+		kinds, categories, match, exclude string
+		names                             []string
+		accepted                          []string
+	}{
+		{"", "", "", "", []string{"Added1", "Breaking1", "Unchanged1"}, []string{"Added1", "Breaking1", "Unchanged1"}},
+		{"breaking,removed", "", "", "", []string{"Added1", "Breaking1", "Removed1", "BreakingT1"}, []string{"Breaking1", "Removed1", "BreakingT1"}},
+		{"Added", "", "", "", []string{"Added1", "AddedT1", "Breaking1"}, []string{"Added1", "AddedT1"}},
+		{"", "type", "", "", []string{"Added1", "AddedT1", "AddedV1"}, []string{"AddedT1"}},
+		{"", "func,VALUE", "", "", []string{"Added1", "AddedT1", "AddedV1"}, []string{"Added1", "AddedV1"}},
+		// Names are matched with the package
+		{"", "", `^testdata\.Breaking`, "", []string{"Breaking1", "BreakingT1", "Added1"}, []string{"Breaking1", "BreakingT1"}},
+		{"", "", `^Breaking`, "", []string{"Breaking1"}, []string{}},
+		{"", "", "", `T\d$`, []string{"Breaking1", "BreakingT1", "BreakingV1"}, []string{"Breaking1", "BreakingV1"}},
+		{"breaking", "func", "Breaking", "[2-6]$", []string{"Breaking1", "Breaking2", "BreakingT1", "Compatible1"}, []string{"Breaking1"}},
+		// Spaces around the names are ignored as in -fail-on
+		{"breaking, REMOVED", " type ", "", "", []string{"Breaking1", "BreakingT1", "RemovedT1", "AddedT1"}, []string{"BreakingT1", "RemovedT1"}},
+		{"unchanged", "", "", "", []string{"Unchanged1", "UnchangedT1", "Compatible1"}, []string{"Unchanged1", "UnchangedT1"}},
+		{"compatible", "value", "", "", []string{"Compatible1", "CompatibleT1", "CompatibleV1"}, []string{"CompatibleV1"}},
+		// The pattern matches anywhere unless anchored
+		{"", "", `T1`, "", []string{"BreakingT1", "Breaking1", "AddedT1"}, []string{"BreakingT1", "AddedT1"}},
+		{"", "", `Breaking`, `Breaking`, []string{"Breaking1", "Added1"}, []string{}},
+	}
+
+	for i, test := range tests {
+		filter, err := newChangeFilter(test.kinds, test.categories, test.match, test.exclude)
+		require.NoError(t, err, "#%d", i)
+
+		accepted := []string{}
+		for _, name := range test.names {
+			e, ok := all[name]
+			require.True(t, ok, name)
+			if filter.accepts(e) {
+				accepted = append(accepted, name)
+			}
+		}
+		assert.Equal(t, util.SortedStringSet(test.accepted), util.SortedStringSet(accepted), "#%d", i)
+	}
+}
+
+func TestNewChangeFilterErrors(t *testing.T) {
+	tests := []struct {
+		kinds, categories, match, exclude string
+	}{
+		{"bogus", "", "", ""},
+		{"breaking,bogus", "", "", ""},
+		{"breaking,", "", "", ""},
+		{"deprecated", "", "", ""},
+		{"", "method", "", ""},
+		{"", "func,", "", ""},
+		{"", "", "(", ""},
+		{"", "", "", "["},
+	}
+
+	for _, test := range tests {
+		_, err := newChangeFilter(test.kinds, test.categories, test.match, test.exclude)
+		assert.Error(t, err, "%+v", test)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testdataEntries returns the changes between testdata/before and testdata/after keyed by their names.
+func testdataEntries(t *testing.T) map[string]changeEntry {
+	before, err := gompatible.LoadFS(os.DirFS("../../testdata"), "before", "testdata", false)
+	require.NoError(t, err)
+	after, err := gompatible.LoadFS(os.DirFS("../../testdata"), "after", "testdata", false)
+	require.NoError(t, err)
+
+	entries := map[string]changeEntry{}
+	for _, e := range listChanges(diffPackageSets(before, after)) {
+		entries[e.Name] = e
+	}
+	return entries
+}
+
+// gitRepo creates a temporary git repository on branch main, returning its path
+// and the function to run git in it, which returns the trimmed output.
+// The caller should remove the directory.
+func gitRepo(t *testing.T) (string, func(args ...string) string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+
+	// resolve symlinks eg. /tmp on macOS as git does
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+
+	git("init", "-q")
+	git("checkout", "-q", "-b", "main")
+
+	return dir, git
+}
+
+// writeFiles writes the files of contents keyed by the slash-separated paths under dir.
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	for name, content := range contents {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
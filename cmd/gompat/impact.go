@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go/token"
+	"go/types"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// changeImpact is the uses of a changed API in the dependent packages.
+type changeImpact struct {
+	Package string
+	Name    string
+	Change  gompatible.Change
+	Usages  []*gompatible.Usage
+	// Broken is the number of the uses broken by the change
+	Broken int
+}
+
+func runImpact(args []string) {
+	flags := flag.NewFlagSet("impact", flag.ExitOnError)
+	flagDependents := flags.String("dependents", "", "comma-separated local `directories` of the dependent packages (may have \"/...\" suffix)")
+	flagRecurse := flags.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
+	flagVerbose := flags.Bool("v", false, "show also the uses not broken by the changes")
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) < 1 || *flagDependents == "" {
+		usage()
+	}
+
+	conf, err := loadProjectConfig("")
+	dieIf(err)
+
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = conf.packages()
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	repo, err := repoDir(paths)
+	dieIf(err)
+
+	rev1, rev2, err := resolveRevisionRange(args[0], repo)
+	dieIf(err)
+
+	loader := &packageLoader{
+		Recurse: *flagRecurse,
+		Exclude: conf.Exclude,
+	}
+
+	diffs, err := loader.diff(paths, rev1, rev2)
+	dieIf(err)
+
+	fset := token.NewFileSet()
+	deps := []*gompatible.Dependent{}
+	for _, pattern := range strings.Split(*flagDependents, ",") {
+		dir, recurse := parsePathArg(pattern)
+		if dir == "" {
+			dir = "."
+		}
+
+		d, err := gompatible.LoadDependents(fset, dir, recurse)
+		dieIf(err)
+		deps = append(deps, d...)
+	}
+
+	impacts := findImpacts(diffs, deps)
+	if printImpacts(os.Stdout, impacts, *flagVerbose) > 0 {
+		os.Exit(1)
+	}
+}
+
+// findImpacts finds the uses of the changed APIs in the dependents, and ranks the changes
+// by the numbers of the uses broken and then of all the uses.
+func findImpacts(diffs map[string]gompatible.PackageChanges, deps []*gompatible.Dependent) []*changeImpact {
+	impacts := []*changeImpact{}
+
+	for _, pkgName := range util.SortedStringSet(util.MapKeys(diffs)) {
+		importPath := importPathOf(pkgName)
+
+		index := map[string]*changeImpact{}
+		for _, u := range gompatible.FindUsages(diffs[pkgName], importPath, deps) {
+			ci := index[u.Name]
+			if ci == nil {
+				ci = &changeImpact{Package: importPath, Name: u.Name, Change: u.Change}
+				index[u.Name] = ci
+				impacts = append(impacts, ci)
+			}
+
+			ci.Usages = append(ci.Usages, u)
+			if u.Broken() {
+				ci.Broken++
+			}
+		}
+	}
+
+	sort.SliceStable(impacts, func(i, j int) bool {
+		if impacts[i].Broken != impacts[j].Broken {
+			return impacts[i].Broken > impacts[j].Broken
+		}
+		return len(impacts[i].Usages) > len(impacts[j].Usages)
+	})
+
+	return impacts
+}
+
+// printImpacts prints the changes with the uses broken by them, or all the uses if verbose.
+// It returns the number of the uses broken.
+func printImpacts(w io.Writer, impacts []*changeImpact, verbose bool) int {
+	var broken int
+
+	for _, ci := range impacts {
+		broken += ci.Broken
+		if ci.Broken == 0 && !verbose {
+			continue
+		}
+
+		kind := strings.ToLower(ci.Change.Kind().String())
+		fmt.Fprintf(w, "%s.%s (%s): uses: %d, broken: %d\n", ci.Package, ci.Name, kind, len(ci.Usages), ci.Broken)
+
+		for _, u := range ci.Usages {
+			if !u.Broken() && !verbose {
+				continue
+			}
+
+			pos := u.Pos
+			pos.Filename = relPath(pos.Filename)
+
+			usage := strings.ToLower(u.Kind.String())
+			if u.Broken() {
+				fmt.Fprintf(w, "  %s: %s: %s\n", pos, usage, errorMessage(u.Err))
+			} else {
+				fmt.Fprintf(w, "  %s: %s\n", pos, usage)
+			}
+		}
+	}
+
+	return broken
+}
+
+// importPathOf returns the import path of the package named after its directory,
+// which is the case outside GOPATH, by the module the directory belongs to.
+func importPathOf(pkgName string) string {
+	if fi, err := os.Stat(pkgName); err != nil || fi.IsDir() == false {
+		return pkgName
+	}
+
+	pkgDir, err := filepath.Abs(pkgName)
+	if err != nil {
+		return pkgName
+	}
+
+	for dir := pkgDir; ; dir = filepath.Dir(dir) {
+		if mod := modulePath(dir); mod != "" {
+			rel, err := filepath.Rel(dir, pkgDir)
+			if err != nil {
+				return pkgName
+			}
+			return path.Join(mod, filepath.ToSlash(rel))
+		}
+
+		if dir == filepath.Dir(dir) {
+			return pkgName
+		}
+	}
+}
+
+// relPath returns the path relative to the working directory if it is under it.
+func relPath(name string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+
+	if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+
+	return name
+}
+
+// errorMessage returns the first line of the error message, without the position for type errors.
+func errorMessage(err error) string {
+	msg := err.Error()
+	if err, ok := err.(types.Error); ok {
+		msg = err.Msg
+	}
+
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		return msg[:i]
+	}
+	return msg
+}
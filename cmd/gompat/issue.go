@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go/token"
+
+	"github.com/motemen/gompatible"
+)
+
+// issue is a breaking or removed API change reported to code-scanning tools,
+// located at the declaration of the API.
+type issue struct {
+	Rule    string
+	Message string
+	Pos     token.Position
+
+	Acknowledged *suppression
+}
+
+// listIssues picks breaking and removed changes from the entries.
+// Breaking changes are located at the declarations in the after revision
+// and removals at ones in the before revision.
+func listIssues(entries []changeEntry) []issue {
+	issues := []issue{}
+
+	for _, e := range entries {
+		switch e.Change.Kind() {
+		case gompatible.ChangeBreaking:
+			msg := fmt.Sprintf("Breaking change of %s %s", e.Category, e.Name)
+			if reasons := gompatible.Reasons(e.Change); len(reasons) > 0 {
+				msg += ": " + strings.Join(reasons, "; ")
+			}
+			issues = append(issues, issue{
+				Rule:    "breaking",
+				Message: msg,
+				Pos:     e.Change.PosAfter(),
+
+				Acknowledged: e.Acknowledged,
+			})
+
+		case gompatible.ChangeRemoved:
+			issues = append(issues, issue{
+				Rule:    "removed",
+				Message: fmt.Sprintf("Removal of %s %s", e.Category, e.Name),
+				Pos:     e.Change.PosBefore(),
+
+				Acknowledged: e.Acknowledged,
+			})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"go/token"
+
+	"github.com/motemen/gompatible"
+)
+
+// jsonSchemaVersion is the version of the JSON output format.
+// Increment it on incompatible changes to the format.
+const jsonSchemaVersion = 1
+
+type jsonOutput struct {
+	Version int          `json:"version"`
+	Changes []jsonChange `json:"changes"`
+}
+
+type jsonChange struct {
+	Package   string        `json:"package"`
+	Name      string        `json:"name"`
+	Category  string        `json:"category"`
+	Kind      string        `json:"kind"`
+	Before    string        `json:"before,omitempty"`
+	After     string        `json:"after,omitempty"`
+	Reasons   []string      `json:"reasons,omitempty"`
+	PosBefore *jsonPosition `json:"posBefore,omitempty"`
+	PosAfter  *jsonPosition `json:"posAfter,omitempty"`
+
+	Acknowledged *jsonAcknowledgement `json:"acknowledged,omitempty"`
+	Example      string               `json:"example,omitempty"`
+	Corpus       *jsonCorpusUsage     `json:"corpus,omitempty"`
+}
+
+type jsonCorpusUsage struct {
+	References int `json:"references"`
+	Modules    int `json:"modules"`
+}
+
+type jsonAcknowledgement struct {
+	Justification string `json:"justification,omitempty"`
+	Expires       string `json:"expires,omitempty"`
+}
+
+type jsonPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func newJSONPosition(pos token.Position) *jsonPosition {
+	if pos.IsValid() == false {
+		return nil
+	}
+
+	return &jsonPosition{
+		File:   pos.Filename,
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+}
+
+func printJSON(w io.Writer, entries []changeEntry) error {
+	out := jsonOutput{
+		Version: jsonSchemaVersion,
+		Changes: make([]jsonChange, len(entries)),
+	}
+
+	for i, e := range entries {
+		out.Changes[i] = jsonChange{
+			Package:   e.Package,
+			Name:      e.Name,
+			Category:  string(e.Category),
+			Kind:      strings.ToLower(e.Change.Kind().String()),
+			Before:    e.Change.ShowBefore(),
+			After:     e.Change.ShowAfter(),
+			Reasons:   gompatible.Reasons(e.Change),
+			PosBefore: newJSONPosition(e.Change.PosBefore()),
+			PosAfter:  newJSONPosition(e.Change.PosAfter()),
+			Example:   e.Example,
+		}
+		if u := e.Corpus; u != nil {
+			out.Changes[i].Corpus = &jsonCorpusUsage{
+				References: u.References,
+				Modules:    u.Modules,
+			}
+		}
+		if s := e.Acknowledged; s != nil {
+			out.Changes[i].Acknowledged = &jsonAcknowledgement{
+				Justification: s.Justification,
+				Expires:       s.Expires,
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintJSON(t *testing.T) {
+	all := testdataEntries(t)
+
+	acked := all["Removed1"]
+	acked.Acknowledged = &suppression{Symbol: "Removed1", Justification: "unused", Expires: "2100-01-01"}
+	acked.Example = "func _() {}\n"
+	acked.Corpus = &gompatible.CorpusUsage{References: 3, Modules: 2}
+
+	tests := []struct {
+		entry    changeEntry
+		expected jsonChange
+		lines    [2]int
+	}{
+		{
+			all["Breaking1"],
+			jsonChange{Package: "testdata", Name: "Breaking1", Category: "func", Kind: "breaking", Before: "func Breaking1(n int)", After: "func Breaking1(n int, b bool)", Reasons: []string{"parameter #2 added: bool"}},
+			[2]int{15, 15},
+		},
+		{
+			all["BreakingT1"],
+			jsonChange{
+				Package: "testdata", Name: "BreakingT1", Category: "type", Kind: "breaking",
+				Before: "type BreakingT1 struct {\n\tXXX string\n}", After: "type BreakingT1 struct {\n\tYYY int\n}",
+				Reasons: []string{"field XXX removed", "field YYY added"},
+			},
+			[2]int{38, 38},
+		},
+		{
+			all["Added1"],
+			jsonChange{Package: "testdata", Name: "Added1", Category: "func", Kind: "added", After: "func Added1()"},
+			[2]int{0, 19},
+		},
+		{
+			acked,
+			jsonChange{
+				Package: "testdata", Name: "Removed1", Category: "func", Kind: "removed", Before: "func Removed1()",
+				Acknowledged: &jsonAcknowledgement{Justification: "unused", Expires: "2100-01-01"},
+				Example:      "func _() {}\n",
+				Corpus:       &jsonCorpusUsage{References: 3, Modules: 2},
+			},
+			[2]int{acked.Change.PosBefore().Line, 0},
+		},
+	}
+
+	entries := make([]changeEntry, len(tests))
+	for i, test := range tests {
+		entries[i] = test.entry
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printJSON(&buf, entries))
+
+	var out jsonOutput
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, jsonSchemaVersion, out.Version)
+	require.Len(t, out.Changes, len(tests))
+
+	for i, test := range tests {
+		c := out.Changes[i]
+
+		// Positions depend on where the tree is checked out
+		for j, pos := range []*jsonPosition{c.PosBefore, c.PosAfter} {
+			if test.lines[j] == 0 {
+				assert.Nil(t, pos, test.expected.Name)
+			} else if assert.NotNil(t, pos, test.expected.Name) {
+				assert.Equal(t, test.lines[j], pos.Line, test.expected.Name)
+			}
+		}
+		c.PosBefore, c.PosAfter = nil, nil
+
+		assert.Equal(t, test.expected, c)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+
+	"golang.org/x/mod/modfile"
+)
+
+// parseRevisionRange parses a revision range specification, which is one of:
+//   - <rev1>..<rev2>
+//   - <rev1>.. (same as <rev1>..WORKTREE)
+//   - <rev1> (same as <rev1>~1..<rev1>)
+//   - WORKTREE or INDEX (same as HEAD..WORKTREE or HEAD..INDEX)
+func parseRevisionRange(spec string) (string, string) {
+	revs := strings.SplitN(spec, "..", 2)
+	if len(revs) == 1 {
+		if revs[0] == gompatible.RevisionWorktree || revs[0] == gompatible.RevisionIndex {
+			return "HEAD", revs[0]
+		}
+		return revs[0] + "~1", revs[0]
+	}
+
+	return revs[0], revs[1]
+}
+
+// resolveRevisionRange parses the revision range specification like parseRevisionRange,
+// and in addition, resolves "<rev1>...<rev2>" to "<merge base of rev1 and rev2>..<rev2>" as git does.
+// <rev2> defaults to HEAD.
+func resolveRevisionRange(spec string, repo string) (string, string, error) {
+	revs := strings.SplitN(spec, "...", 2)
+	if len(revs) == 1 {
+		rev1, rev2 := parseRevisionRange(spec)
+		return rev1, rev2, nil
+	}
+
+	if revs[1] == "" {
+		revs[1] = "HEAD"
+	}
+
+	if err := requireGit(repo, `"<rev1>...<rev2>"`); err != nil {
+		return "", "", err
+	}
+
+	base, err := gitMergeBase(repo, revs[0], revs[1])
+	if err != nil {
+		return "", "", err
+	}
+
+	return base, revs[1], nil
+}
+
+// baseRevisionRange returns the range from the merge base of head and the default branch
+// of the repository to head, which is compared by -base.
+func baseRevisionRange(repo, head string) (string, string, error) {
+	if err := requireGit(repo, "-base"); err != nil {
+		return "", "", err
+	}
+
+	branch, err := gitDefaultBranch(repo)
+	if err != nil {
+		return "", "", err
+	}
+
+	return resolveRevisionRange(branch+"..."+head, repo)
+}
+
+// repoDir returns the directory of the first of paths, which is used to run VCS commands.
+func repoDir(paths []string) (string, error) {
+	path, _ := parsePathArg(paths[0])
+
+	dir, err := gompatible.NewDirSpec(path, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	return dir.Path, nil
+}
+
+// parsePathArg takes an import path argument and reports whether
+// it ends with "/..." i.e. packages should be loaded recursively.
+func parsePathArg(path string) (string, bool) {
+	if strings.HasSuffix(path, "...") {
+		return strings.TrimSuffix(path, "..."), true
+	}
+
+	return path, false
+}
+
+// isRecursive reports whether any of the paths or the flag tells to load packages recursively.
+func isRecursive(paths []string, flag bool) bool {
+	for _, path := range paths {
+		if _, recurse := parsePathArg(path); recurse {
+			return true
+		}
+	}
+
+	return flag
+}
+
+// packageLoader loads packages at revisions.
+type packageLoader struct {
+	VCS     string
+	Recurse bool
+	Exclude []string
+}
+
+// diff loads the packages at paths of two revisions and computes the changes between them.
+// Each of paths may have "/..." suffix to load packages recursively.
+func (l *packageLoader) diff(paths []string, rev1, rev2 string) (map[string]gompatible.PackageChanges, error) {
+	pkgs1, err := l.loadAll(paths, rev1)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs2, err := l.loadAll(paths, rev2)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffPackageSets(pkgs1, pkgs2), nil
+}
+
+func (l *packageLoader) loadAll(paths []string, rev string) (map[string]*gompatible.Package, error) {
+	packages := map[string]*gompatible.Package{}
+
+	for _, path := range paths {
+		path, recurse := parsePathArg(path)
+
+		pkgs, err := l.load(path, rev, l.Recurse || recurse)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, pkg := range pkgs {
+			packages[name] = pkg
+		}
+	}
+
+	return packages, nil
+}
+
+func (l *packageLoader) load(path string, rev string, recurse bool) (map[string]*gompatible.Package, error) {
+	dir, err := gompatible.NewDirSpec(path, l.VCS, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	dir.Exclude = l.Exclude
+
+	return gompatible.LoadDir(dir, recurse)
+}
+
+// diffDirs loads the packages at pkg, the slash-separated path which may have "/..." suffix,
+// in two directories or zip archives and computes the changes between them.
+// Packages are named after the module path of either of them, or the base name of loc2
+// without the version suffix as in the module cache eg. "@v1.0.0".
+func (l *packageLoader) diffDirs(loc1, loc2 string, zipped bool, pkg string) (map[string]gompatible.PackageChanges, error) {
+	pkg, recurse := parsePathArg(pkg)
+	pkg = strings.TrimSuffix(pkg, "/")
+	if pkg == "" {
+		pkg = "."
+	}
+
+	roots := make([]*gompatible.DirSpec, 2)
+	for i, loc := range []string{loc1, loc2} {
+		var err error
+		if zipped {
+			roots[i], err = gompatible.NewZipDirSpec(loc, ".")
+		} else {
+			roots[i], err = gompatible.NewDirSpec(loc, "", "")
+			if err == nil {
+				roots[i].ImportPath = modulePath(loc)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	name := roots[0].ImportPath
+	if name == "" {
+		name = roots[1].ImportPath
+	}
+	if name == "" {
+		name = filepath.Base(loc2)
+		if zipped {
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		if at := strings.LastIndex(name, "@"); at > 0 {
+			name = name[:at]
+		}
+	}
+
+	pkgs := make([]map[string]*gompatible.Package, 2)
+	for i, root := range roots {
+		dir := *root // copy
+		dir.Path = filepath.Join(root.Path, filepath.FromSlash(pkg))
+		dir.ImportPath = path.Join(name, pkg)
+		dir.Exclude = l.Exclude
+
+		var err error
+		pkgs[i], err = gompatible.LoadDir(&dir, l.Recurse || recurse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return diffPackageSets(pkgs[0], pkgs[1]), nil
+}
+
+// modulePath returns the module path declared in the go.mod in dir, if any.
+func modulePath(dir string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+
+	return modfile.ModulePath(data)
+}
+
+func diffPackageSets(pkgs1, pkgs2 map[string]*gompatible.Package) map[string]gompatible.PackageChanges {
+	diffs := map[string]gompatible.PackageChanges{}
+
+	for _, name := range util.SortedStringSet(util.MapKeys(pkgs1), util.MapKeys(pkgs2)) {
+		diffs[name] = gompatible.DiffPackages(
+			pkgs1[name], pkgs2[name],
+		)
+	}
+
+	return diffs
+}
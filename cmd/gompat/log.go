@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/daviddengcn/go-colortext"
+)
+
+func runLog(args []string) {
+	flags := flag.NewFlagSet("log", flag.ExitOnError)
+	flagRecurse := flags.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
+	flagDiff := flags.Bool("d", false, "run diff on multi-line changes")
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) < 1 {
+		usage()
+	}
+
+	conf, err := loadProjectConfig("")
+	dieIf(err)
+
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = conf.packages()
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	repo, err := repoDir(paths)
+	dieIf(err)
+	dieIf(requireGit(repo, "log"))
+
+	rev1, rev2, err := resolveRevisionRange(args[0], repo)
+	dieIf(err)
+
+	switch rev2 {
+	case "", gompatible.RevisionWorktree, gompatible.RevisionIndex:
+		dieIf(fmt.Errorf("log requires a range of commits: %q", args[0]))
+	}
+
+	commits, err := gitLog(repo, rev1, rev2)
+	dieIf(err)
+
+	loader := &packageLoader{
+		VCS:     "",
+		Recurse: *flagRecurse,
+		Exclude: conf.Exclude,
+	}
+
+	w := &apiLog{
+		loader: loader,
+		paths:  paths,
+		loaded: map[string]map[string]*gompatible.Package{},
+		refs:   map[string]int{},
+	}
+	for _, c := range commits {
+		if len(c.Parents) > 0 {
+			w.refs[c.Parents[0]]++
+		}
+	}
+
+	showHeader := isRecursive(paths, *flagRecurse) || len(paths) > 1
+
+	var shown bool
+	for _, c := range commits {
+		entries := w.changes(c)
+		if len(entries) == 0 {
+			continue
+		}
+
+		if shown {
+			fmt.Println()
+		}
+		shown = true
+
+		ct.ChangeColor(ct.Yellow, false, ct.None, false)
+		fmt.Print(c.ShortHash)
+		ct.ResetColor()
+		fmt.Printf(" %s\n", c.Subject)
+
+		printText(entries, showHeader, *flagDiff, false)
+	}
+}
+
+// apiLog computes API changes commit by commit. The packages loaded at a commit
+// are kept until all the commits whose first parent it is are processed,
+// so that each revision is loaded only once.
+type apiLog struct {
+	loader revisionLoader
+	paths  []string
+	// loaded maps commit hashes to the packages at them
+	loaded map[string]map[string]*gompatible.Package
+	// refs counts the commits yet to be processed whose first parent is the key
+	refs map[string]int
+}
+
+// revisionLoader loads the packages at a revision, which is implemented by packageLoader.
+type revisionLoader interface {
+	loadAll(paths []string, rev string) (map[string]*gompatible.Package, error)
+}
+
+// load returns the packages at the revision. A revision failed to load is warned
+// and has the fallback packages, ie. the ones of its first parent, instead.
+func (w *apiLog) load(rev string, fallback map[string]*gompatible.Package) map[string]*gompatible.Package {
+	if pkgs, ok := w.loaded[rev]; ok {
+		return pkgs
+	}
+
+	pkgs, err := w.loader.loadAll(w.paths, rev)
+	if err != nil {
+		warnf("%s: %s", rev, err)
+		pkgs = fallback
+	}
+
+	w.loaded[rev] = pkgs
+	return pkgs
+}
+
+// changes returns the API changes introduced by the commit against its first parent.
+func (w *apiLog) changes(c gitCommit) []changeEntry {
+	var (
+		parent string
+		pkgs1  map[string]*gompatible.Package
+	)
+	if len(c.Parents) > 0 {
+		parent = c.Parents[0]
+		pkgs1 = w.load(parent, nil)
+
+		if w.refs[parent]--; w.refs[parent] == 0 {
+			delete(w.loaded, parent)
+		}
+	}
+
+	pkgs2 := w.load(c.Hash, pkgs1)
+	if w.refs[c.Hash] == 0 {
+		delete(w.loaded, c.Hash)
+	}
+
+	entries := listChanges(diffPackageSets(pkgs1, pkgs2))
+
+	return filterChanges(entries, func(e changeEntry) bool {
+		return e.Change.Kind() != gompatible.ChangeUnchanged
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoader loads the testdata packages by the revisions, failing on unknown ones.
+type fakeLoader map[string]string
+
+func (l fakeLoader) loadAll(paths []string, rev string) (map[string]*gompatible.Package, error) {
+	dir, ok := l[rev]
+	if !ok {
+		return nil, fmt.Errorf("cannot load %s", rev)
+	}
+	return gompatible.LoadFS(os.DirFS("../../testdata"), dir, "testdata", false)
+}
+
+func TestAPILogLoadFailure(t *testing.T) {
+	commits := []gitCommit{
+		{Hash: "a"},
+		{Hash: "b", Parents: []string{"a"}},
+		{Hash: "c", Parents: []string{"b"}},
+		{Hash: "d", Parents: []string{"c"}},
+	}
+
+	w := &apiLog{
+		loader: fakeLoader{"a": "before", "b": "after", "d": "after"},
+		loaded: map[string]map[string]*gompatible.Package{},
+		refs:   map[string]int{},
+	}
+	for _, c := range commits {
+		if len(c.Parents) > 0 {
+			w.refs[c.Parents[0]]++
+		}
+	}
+
+	require.NotEmpty(t, w.changes(commits[0]))
+	assert.NotEmpty(t, w.changes(commits[1]))
+	// c failed to load, and is supposed to have the same API as b
+	assert.Empty(t, w.changes(commits[2]))
+	assert.Empty(t, w.changes(commits[3]))
+}
@@ -8,134 +8,262 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/motemen/gompatible"
-	"github.com/motemen/gompatible/internal/util"
 
 	"github.com/daviddengcn/go-colortext"
 )
 
+// subcommands are invoked by the first argument instead of showing API changes.
+var subcommands = map[string]func(args []string){
+	"bisect":    runBisect,
+	"blame":     runBlame,
+	"changelog": runChangelog,
+	"impact":    runImpact,
+	"log":       runLog,
+	"shim":      runShim,
+	"since":     runSince,
+}
+
 func usage() {
-	fmt.Printf("Usage: %s [-a] [-r] <rev1>[..<rev2>] [<import path>[/...]]\n", os.Args[0])
+	fmt.Printf("Usage: %s [-a] [-d] [-r] [-v] [-format=<format>|-template=<file>] <rev1>[..<rev2>] [<import path>[/...]...]\n", os.Args[0])
+	fmt.Printf("       %s [<flags>] -base [<rev> [<import path>[/...]...]]\n", os.Args[0])
+	fmt.Printf("       %s [<flags>] -dirs|-zip <A> <B> [<package path>[/...]]\n", os.Args[0])
+	fmt.Printf("       %s changelog [-r] <rev1>..<rev2> [<import path>[/...]...]\n", os.Args[0])
+	fmt.Printf("       %s log [-r] [-d] <rev1>..<rev2> [<import path>[/...]...]\n", os.Args[0])
+	fmt.Printf("       %s since [-r] [-format=json|api] [<import path>[/...]...]\n", os.Args[0])
+	fmt.Printf("       %s bisect [-d] <good>..<bad> [<import path>.]<name>\n", os.Args[0])
+	fmt.Printf("       %s blame [-d] [<rev1>..<rev2>] [<import path>.]<name>\n", os.Args[0])
+	fmt.Printf("       %s shim [-o <file>] [-f] <rev1>..<rev2> [<import path>]\n", os.Args[0])
+	fmt.Printf("       %s impact [-r] [-v] -dependents <dir>[/...][,...] <rev1>..<rev2> [<import path>[/...]...]\n", os.Args[0])
 	flag.PrintDefaults()
 	os.Exit(1)
 }
 
 func main() {
 	var (
-		flagAll     = flag.Bool("a", false, "show also unchanged APIs")
-		flagRecurse = flag.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
-		flagDiff    = flag.Bool("d", false, "run diff on multi-line changes")
+		flagAll      = flag.Bool("a", false, "show also unchanged APIs")
+		flagRecurse  = flag.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
+		flagDiff     = flag.Bool("d", false, "run diff on multi-line changes")
+		flagVerbose  = flag.Bool("v", false, "show also the source positions of the changes")
+		flagFormat   = flag.String("format", "text", `output format ("text", "json", "sarif" or "checkstyle")`)
+		flagFailOn   = flag.String("fail-on", strings.Join(defaultFailOn, ","), "comma-separated `kinds` of changes to exit with failure, or \"none\" (default with -severity-exit also \"added,compatible\")")
+		flagSeverity = flag.Bool("severity-exit", false, "exit with 3 if a major version bump is needed, 2 if minor, instead of 1")
+		flagKinds    = flag.String("kinds", "", "show only changes of the comma-separated `kinds` (overrides -a)")
+		flagOnly     = flag.String("only", "", "show only APIs of the comma-separated `categories` (func, type or value)")
+		flagMatch    = flag.String("match", "", "show only APIs whose \"<package>.<name>\" match the `regexp`")
+		flagExclude  = flag.String("exclude", "", "do not show APIs whose \"<package>.<name>\" match the `regexp`")
+		flagSkipPkg  = flag.String("exclude-pkg", "", "comma-separated glob `patterns` of directories to skip in recursive mode")
+		flagBase     = flag.Bool("base", false, "compare the revision (default HEAD) with the merge base of it and the default branch")
+		flagDirs     = flag.Bool("dirs", false, "compare two directories instead of revisions")
+		flagZip      = flag.Bool("zip", false, "compare two zip archives, eg. module zips, instead of revisions")
+		flagCorpus   = flag.String("corpus", "", "count references to the APIs in the modules in the module cache `dir`, eg. $GOMODCACHE")
+		flagExamples = flag.Bool("examples", false, "show examples of client code broken by breaking changes and removals")
+		flagVerify   = flag.Bool("verify", false, "verify the classifications of the changes by type-checking synthesized client programs")
+		flagTests    = flag.Bool("tests", false, "type-check the external tests and examples of rev1 against rev2")
+		flagVCS      = flag.String("vcs", "", "`name` of the VCS, eg. \"git\" or \"hg\" (default detected)")
+		flagConfig   = flag.String("config", "", "read configuration from `file` (default \""+configFileName+"\" searched upwards)")
+		flagTemplate = flag.String("template", "", "render changes with the text/template `file` instead of -format")
 	)
 	flag.Parse()
 	flag.Usage = usage
 
 	args := flag.Args()
-	if len(args) < 1 {
+	if *flagDirs || *flagZip {
+		if len(args) < 2 || len(args) > 3 {
+			usage()
+		}
+	} else if len(args) < 1 && *flagBase == false {
 		usage()
 	}
 
-	// TODO: support mercurial and other vcs
-	vcsType := "git"
-
-	revs := strings.SplitN(args[0], "..", 2)
-	if len(revs) == 1 {
-		revs = []string{revs[0] + "~1", revs[0]}
-	} else if revs[1] == "" {
-		revs = []string{revs[0], ""}
-	}
-
-	path := "."
-	if len(args) >= 2 {
-		path = args[1]
-
-		if strings.HasSuffix(path, "...") {
-			path = strings.TrimSuffix(path, "...")
-			*flagRecurse = true
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			cmd(args[1:])
+			return
 		}
 	}
 
-	dir1, err := gompatible.NewDirSpec(path, vcsType, revs[0])
-	dieIf(err)
-
-	pkgs1, err := gompatible.LoadDir(dir1, *flagRecurse)
+	conf, err := loadProjectConfig(*flagConfig)
 	dieIf(err)
+	dieIf(conf.applyFlagDefaults(flag.CommandLine))
+
+	var tmpl *template.Template
+	if *flagTemplate != "" {
+		tmpl, err = parseTemplateFile(*flagTemplate)
+		dieIf(err)
+	} else {
+		switch *flagFormat {
+		case "text", "json", "sarif", "checkstyle":
+		default:
+			dieIf(fmt.Errorf("unknown format: %q", *flagFormat))
+		}
+	}
 
-	dir2, err := gompatible.NewDirSpec(path, vcsType, revs[1])
+	filter, err := newChangeFilter(*flagKinds, *flagOnly, *flagMatch, *flagExclude)
 	dieIf(err)
 
-	pkgs2, err := gompatible.LoadDir(dir2, *flagRecurse)
+	failOnNames := strings.Split(*flagFailOn, ",")
+	if *flagSeverity && isFlagSet(flag.CommandLine, "fail-on") == false {
+		failOnNames = severityFailOn
+	}
+	failOn, err := parseFailOn(failOnNames)
 	dieIf(err)
 
-	diffs := map[string]gompatible.PackageChanges{}
+	exclude := conf.Exclude
+	if *flagSkipPkg != "" {
+		exclude = append(exclude, strings.Split(*flagSkipPkg, ",")...)
+	}
 
-	for _, name := range util.SortedStringSet(util.MapKeys(pkgs1), util.MapKeys(pkgs2)) {
-		diffs[name] = gompatible.DiffPackages(
-			pkgs1[name], pkgs2[name],
-		)
+	loader := &packageLoader{
+		VCS:     *flagVCS,
+		Recurse: *flagRecurse,
+		Exclude: exclude,
 	}
 
-	var packageIndex int
-	var hasBreaking bool
-	for _, name := range util.SortedStringSet(util.MapKeys(diffs)) {
-		diff := diffs[name]
+	var (
+		paths []string
+		diffs map[string]gompatible.PackageChanges
+	)
+	if *flagDirs || *flagZip {
+		paths = []string{"."}
+		if len(args) == 3 {
+			paths = args[2:]
+		}
 
-		var headerShown bool
-		printHeader := func() {
-			if *flagRecurse == false {
-				return
+		diffs, err = loader.diffDirs(args[0], args[1], *flagZip, paths[0])
+		dieIf(err)
+	} else {
+		var revSpec, head string
+		if *flagBase {
+			head = "HEAD"
+			if len(args) > 0 {
+				head, args = args[0], args[1:]
 			}
+		} else {
+			revSpec, args = args[0], args[1:]
+		}
 
-			if !headerShown {
-				// FIXME strictly not a package if inspecting local import
-				if packageIndex > 0 {
-					fmt.Println()
-				}
-				fmt.Printf("package %s\n", name)
-				headerShown = true
-				packageIndex++
-			}
+		paths = args
+		if len(paths) == 0 {
+			paths = conf.packages()
+		}
+		if len(paths) == 0 {
+			paths = []string{"."}
 		}
 
-		funcs := diff.Funcs()
-		for _, name := range util.SortedStringSet(util.MapKeys(funcs)) {
-			change := funcs[name]
-			if *flagAll || change.Kind() != gompatible.ChangeUnchanged {
-				printHeader()
-				printChange(change, *flagDiff)
-			}
-			if change.Kind() == gompatible.ChangeBreaking || change.Kind() == gompatible.ChangeRemoved {
-				hasBreaking = true
-			}
+		repo, err := repoDir(paths)
+		dieIf(err)
+
+		var rev1, rev2 string
+		if *flagBase {
+			rev1, rev2, err = baseRevisionRange(repo, head)
+		} else {
+			rev1, rev2, err = resolveRevisionRange(revSpec, repo)
 		}
+		dieIf(err)
 
-		types := diff.Types()
-		for _, name := range util.SortedStringSet(util.MapKeys(types)) {
-			change := types[name]
-			if *flagAll || change.Kind() != gompatible.ChangeUnchanged {
-				printHeader()
-				printChange(change, *flagDiff)
-			}
-			if change.Kind() == gompatible.ChangeBreaking || change.Kind() == gompatible.ChangeRemoved {
-				hasBreaking = true
-			}
+		diffs, err = loader.diff(paths, rev1, rev2)
+		dieIf(err)
+	}
+
+	if *flagVerify {
+		if printVerifications(os.Stdout, diffs, *flagVerbose) > 0 {
+			os.Exit(1)
 		}
+		return
+	}
+
+	if *flagTests {
+		if printTestFailures(os.Stdout, diffs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	entries := listChanges(diffs)
+	for _, w := range applySuppressions(entries, conf.Suppressions, time.Now()) {
+		warnf("%s", w)
+	}
+	acknowledgeExperimental(entries)
+
+	// The filters only narrow the output; the exit status is of all the changes
+	status := exitStatus(entries, failOn, *flagSeverity)
+
+	entries = filterChanges(entries, func(e changeEntry) bool {
+		if *flagKinds == "" && *flagAll == false && e.Change.Kind() == gompatible.ChangeUnchanged {
+			return false
+		}
+		return filter.accepts(e)
+	})
+
+	if *flagExamples {
+		addBreakingExamples(entries, diffs)
+	}
 
-		values := diff.Values()
-		for _, name := range util.SortedStringSet(util.MapKeys(values)) {
-			change := values[name]
-			if *flagAll || change.Kind() != gompatible.ChangeUnchanged {
-				printHeader()
-				printChange(change, *flagDiff)
+	if *flagCorpus != "" {
+		dieIf(addCorpusUsages(entries, diffs, *flagCorpus))
+	}
+
+	switch {
+	case tmpl != nil:
+		dieIf(printTemplate(os.Stdout, tmpl, diffs, entries))
+	case *flagFormat == "text":
+		printText(entries, isRecursive(paths, *flagRecurse) || len(paths) > 1, *flagDiff, *flagVerbose)
+	case *flagFormat == "json":
+		dieIf(printJSON(os.Stdout, entries))
+	case *flagFormat == "sarif":
+		dieIf(printSARIF(os.Stdout, entries))
+	case *flagFormat == "checkstyle":
+		dieIf(printCheckstyle(os.Stdout, entries))
+	}
+
+	os.Exit(status)
+}
+
+func printText(entries []changeEntry, showHeader bool, doDiff bool, showPos bool) {
+	var lastPackage string
+	for i, e := range entries {
+		if showHeader && (i == 0 || e.Package != lastPackage) {
+			// FIXME strictly not a package if inspecting local import
+			if i > 0 {
+				fmt.Println()
 			}
-			if change.Kind() == gompatible.ChangeBreaking || change.Kind() == gompatible.ChangeRemoved {
-				hasBreaking = true
+			fmt.Printf("package %s\n", e.Package)
+		}
+		lastPackage = e.Package
+
+		printChange(e.Change, doDiff)
+		if e.Acknowledged != nil {
+			fmt.Printf("  (%s)\n", acknowledgedNote(e.Acknowledged))
+		}
+		if showPos {
+			printPositions(e.Change)
+		}
+		if u := e.Corpus; u != nil {
+			fmt.Printf("  (corpus references: %d, modules: %d)\n", u.References, u.Modules)
+		}
+		if e.Example != "" {
+			fmt.Println("  breaks:")
+			for _, line := range strings.Split(strings.TrimRight(e.Example, "\n"), "\n") {
+				fmt.Println("    " + line)
 			}
 		}
 	}
+}
+
+func printPositions(c gompatible.Change) {
+	posBefore, posAfter := c.PosBefore(), c.PosAfter()
 
-	if hasBreaking {
-		os.Exit(1)
+	switch {
+	case posBefore.IsValid() && posAfter.IsValid():
+		fmt.Printf("  @ %s -> %s\n", posBefore, posAfter)
+	case posBefore.IsValid():
+		fmt.Printf("  @ %s\n", posBefore)
+	case posAfter.IsValid():
+		fmt.Printf("  @ %s\n", posAfter)
 	}
 }
 
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintSARIF(t *testing.T) {
+	all := testdataEntries(t)
+
+	acked := all["Removed1"]
+	acked.Acknowledged = &suppression{Symbol: "Removed1", Justification: "unused"}
+
+	// Only breaking changes and removals are reported
+	entries := []changeEntry{all["Added1"], all["Breaking1"], acked, all["Compatible1"]}
+
+	var buf bytes.Buffer
+	require.NoError(t, printSARIF(&buf, entries))
+	assert.Equal(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "gompat",
+          "informationUri": "https://github.com/motemen/gompatible",
+          "rules": [
+            {
+              "id": "breaking",
+              "shortDescription": {
+                "text": "Breaking API change"
+              }
+            },
+            {
+              "id": "removed",
+              "shortDescription": {
+                "text": "Removed API"
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "breaking",
+          "level": "error",
+          "message": {
+            "text": "Breaking change of func Breaking1: parameter #2 added: bool"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "after/t.go"
+                },
+                "region": {
+                  "startLine": 15,
+                  "startColumn": 6
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "removed",
+          "level": "error",
+          "message": {
+            "text": "Removal of func Removed1"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "before/t.go"
+                },
+                "region": {
+                  "startLine": 19,
+                  "startColumn": 6
+                }
+              }
+            }
+          ],
+          "suppressions": [
+            {
+              "kind": "external",
+              "justification": "unused"
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`, buf.String())
+}
+
+func TestSARIFURI(t *testing.T) {
+	tests := []struct {
+		filename string
+		uri      string
+	}{
+		{"t.go", "t.go"},
+		{"sub/t.go", "sub/t.go"},
+		{"/src/sub/t.go", "file:///src/sub/t.go"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.uri, sarifURI(test.filename))
+	}
+}
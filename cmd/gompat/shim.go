@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// shimFileName is the default name of the file shims are written to.
+const shimFileName = "gompat_shim.go"
+
+func runShim(args []string) {
+	flags := flag.NewFlagSet("shim", flag.ExitOnError)
+	flagOutput := flags.String("o", "", "write the shim to the `file` (default \""+shimFileName+"\" in the package directory)")
+	flagForce := flags.Bool("f", false, "overwrite the file if it exists")
+	flags.Parse(args)
+
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		usage()
+	}
+
+	path := "."
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	dir, err := repoDir([]string{path})
+	dieIf(err)
+
+	rev1, rev2, err := resolveRevisionRange(args[0], dir)
+	dieIf(err)
+
+	out, err := shimOutput(*flagOutput, dir, rev2)
+	dieIf(err)
+
+	loader := &packageLoader{}
+	diffs, err := loader.diff([]string{path}, rev1, rev2)
+	dieIf(err)
+
+	if len(diffs) != 1 {
+		dieIf(fmt.Errorf("%s: expected one package, found %d", path, len(diffs)))
+	}
+
+	var pc gompatible.PackageChanges
+	for _, name := range util.MapKeys(diffs) {
+		pc = diffs[name]
+	}
+
+	shim, err := gompatible.GenerateShim(pc)
+	dieIf(err)
+
+	for _, name := range util.SortedStringSet(util.MapKeys(shim.Skipped)) {
+		warnf("%s: not restored: %s", name, shim.Skipped[name])
+	}
+
+	if shim.Source == nil {
+		fmt.Println("No APIs to restore.")
+		return
+	}
+
+	if _, err := os.Stat(out); err == nil && !*flagForce {
+		dieIf(fmt.Errorf("%s already exists (use -f to overwrite)", out))
+	}
+
+	dieIf(ioutil.WriteFile(out, shim.Source, 0644))
+
+	for _, name := range util.SortedStringSet(util.MapKeys(shim.Restored)) {
+		fmt.Printf("%s -> %s\n", name, shim.Restored[name])
+	}
+	fmt.Printf("Wrote %s\n", out)
+}
+
+// shimOutput returns the file to write the shim of the package directory dir to, which is out
+// if given. The shim is written into dir only if it is checked against the working tree,
+// where it is supposed to be committed.
+func shimOutput(out, dir, rev2 string) (string, error) {
+	if out != "" {
+		return out, nil
+	}
+
+	switch rev2 {
+	case "", gompatible.RevisionWorktree:
+		return filepath.Join(dir, shimFileName), nil
+	}
+
+	return "", fmt.Errorf("the shim is checked against %s, not the working tree; specify the file by -o", rev2)
+}
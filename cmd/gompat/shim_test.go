@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShimOutput(t *testing.T) {
+	tests := []struct {
+		out, rev2 string
+		file      string
+		err       bool
+	}{
+		{"", "", filepath.Join("lib", shimFileName), false},
+		{"", "WORKTREE", filepath.Join("lib", shimFileName), false},
+		{"", "HEAD", "", true},
+		{"", "INDEX", "", true},
+		{"shim.go", "HEAD", "shim.go", false},
+		{"shim.go", "", "shim.go", false},
+	}
+
+	for _, test := range tests {
+		file, err := shimOutput(test.out, "lib", test.rev2)
+		if test.err {
+			assert.Error(t, err, "%+v", test)
+			continue
+		}
+		if assert.NoError(t, err, "%+v", test) {
+			assert.Equal(t, test.file, file, "%+v", test)
+		}
+	}
+}
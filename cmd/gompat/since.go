@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"go/types"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// sinceRecord is the history of an API across the versions.
+type sinceRecord struct {
+	Package  string `json:"package"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// Added is the version the API first appeared in
+	Added string `json:"added"`
+	// Deprecated is the version the API was deprecated in, if any
+	Deprecated string `json:"deprecated,omitempty"`
+	// Breaking is the last version the API was changed incompatibly in, if any
+	Breaking string `json:"breaking,omitempty"`
+	// Removed is the version the API was removed in, if it is not present in the latest version
+	Removed string `json:"removed,omitempty"`
+
+	// decls maps the versions the API changed in to the declarations of it
+	decls map[string]string
+}
+
+func runSince(args []string) {
+	flags := flag.NewFlagSet("since", flag.ExitOnError)
+	flagRecurse := flags.Bool("r", false, `recurse into subdirectories (can be specified by "/..." suffix to the import path)`)
+	flagFormat := flags.String("format", "json", `output format ("json" or "api")`)
+	flags.Parse(args)
+
+	switch *flagFormat {
+	case "json", "api":
+	default:
+		dieIf(fmt.Errorf("unknown format: %q", *flagFormat))
+	}
+
+	conf, err := loadProjectConfig("")
+	dieIf(err)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = conf.packages()
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	repo, err := repoDir(paths)
+	dieIf(err)
+	dieIf(requireGit(repo, "since"))
+
+	tags, err := semverTags(repo)
+	dieIf(err)
+
+	if len(tags) == 0 {
+		dieIf(fmt.Errorf("no semantic version tags found"))
+	}
+
+	loader := &packageLoader{
+		VCS:     "",
+		Recurse: *flagRecurse,
+		Exclude: conf.Exclude,
+	}
+
+	records := sinceRecords(tags, func(tag string) (map[string]*gompatible.Package, error) {
+		return loader.loadAll(paths, tag)
+	})
+
+	sortSinceRecords(records)
+
+	switch *flagFormat {
+	case "json":
+		dieIf(printSinceJSON(os.Stdout, records))
+	case "api":
+		printSinceAPI(os.Stdout, tags, records)
+	}
+}
+
+// sinceRecords walks the versions in order and records the history of each API.
+// A version failed to load is warned and skipped.
+func sinceRecords(versions []string, load func(version string) (map[string]*gompatible.Package, error)) []*sinceRecord {
+	var (
+		records = []*sinceRecord{}
+		index   = map[string]*sinceRecord{}
+		prev    map[string]*gompatible.Package
+	)
+	for _, version := range versions {
+		pkgs, err := load(version)
+		if err != nil {
+			warnf("%s: %s", version, err)
+			continue
+		}
+
+		for _, e := range listChanges(diffPackageSets(prev, pkgs)) {
+			for _, e := range append([]changeEntry{e}, typeMemberEntries(e)...) {
+				key := e.Package + "." + e.Name
+				r := index[key]
+				if r == nil {
+					r = &sinceRecord{
+						Package:  e.Package,
+						Name:     e.Name,
+						Category: string(e.Category),
+						decls:    map[string]string{},
+					}
+					index[key] = r
+					records = append(records, r)
+				}
+
+				r.update(version, e.Change)
+			}
+		}
+
+		prev = pkgs
+	}
+
+	return records
+}
+
+// typeMemberEntries returns the changes of the constructors and the methods of an added or removed type,
+// which are added or removed together but not listed by DiffPackages.
+func typeMemberEntries(e changeEntry) []changeEntry {
+	tc, ok := e.Change.(gompatible.TypeChange)
+	if !ok {
+		return nil
+	}
+
+	var (
+		t      *gompatible.Type
+		change func(f *gompatible.Func) gompatible.Change
+	)
+	switch tc.Kind() {
+	case gompatible.ChangeAdded:
+		t, change = tc.After, func(f *gompatible.Func) gompatible.Change { return gompatible.FuncChange{After: f} }
+	case gompatible.ChangeRemoved:
+		t, change = tc.Before, func(f *gompatible.Func) gompatible.Change { return gompatible.FuncChange{Before: f} }
+	default:
+		return nil
+	}
+
+	entries := []changeEntry{}
+	for _, name := range util.SortedStringSet(util.MapKeys(t.Funcs)) {
+		entries = append(entries, changeEntry{Package: e.Package, Name: name, Category: gompatible.ObjectCategoryFunc, Change: change(t.Funcs[name])})
+	}
+	for _, name := range util.SortedStringSet(util.MapKeys(t.Methods)) {
+		entries = append(entries, changeEntry{Package: e.Package, Name: e.Name + "." + name, Category: gompatible.ObjectCategoryFunc, Change: change(t.Methods[name])})
+	}
+
+	return entries
+}
+
+// update records the change of the API at the version.
+func (r *sinceRecord) update(version string, c gompatible.Change) {
+	switch c.Kind() {
+	case gompatible.ChangeAdded:
+		if r.Added == "" {
+			r.Added = version
+		}
+		r.Removed = ""
+	case gompatible.ChangeRemoved:
+		r.Removed = version
+		r.decls[version] = apiDecl(c.TypesObject())
+		return
+	case gompatible.ChangeBreaking:
+		r.Breaking = version
+	}
+
+	if before, after := gompatible.Deprecation(c); !before && after && r.Deprecated == "" {
+		r.Deprecated = version
+	}
+
+	switch c := c.(type) {
+	case gompatible.FuncChange:
+		r.decls[version] = apiDecl(c.After.Types)
+	case gompatible.TypeChange:
+		r.decls[version] = apiDecl(c.After.Types)
+	case gompatible.ValueChange:
+		r.decls[version] = apiDecl(c.After.Types)
+	}
+}
+
+func sortSinceRecords(records []*sinceRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Package != records[j].Package {
+			return records[i].Package < records[j].Package
+		}
+		return records[i].Name < records[j].Name
+	})
+}
+
+func printSinceJSON(w io.Writer, records []*sinceRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// printSinceAPI prints the records in the format of the api files of Go,
+// eg. "pkg bytes, func Clone([]uint8) []uint8", one section for each version.
+func printSinceAPI(w io.Writer, versions []string, records []*sinceRecord) {
+	for i, version := range versions {
+		var lines []string
+		for _, r := range records {
+			decl := r.decls[version]
+			if r.Added == version {
+				lines = append(lines, fmt.Sprintf("pkg %s, %s", r.Package, decl))
+			}
+			if r.Breaking == version {
+				lines = append(lines, fmt.Sprintf("pkg %s, %s //breaking", r.Package, decl))
+			}
+			if r.Deprecated == version {
+				lines = append(lines, fmt.Sprintf("pkg %s, %s //deprecated", r.Package, decl))
+			}
+			if r.Removed == version {
+				lines = append(lines, fmt.Sprintf("pkg %s, %s //removed", r.Package, decl))
+			}
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "# %s\n", version)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+// apiDecl describes the API object in a line, eg. "func F(int) error",
+// "method (*T) M()", "type T struct" or "const C untyped int".
+func apiDecl(obj types.Object) string {
+	if obj == nil {
+		return ""
+	}
+
+	qf := func(p *types.Package) string {
+		if p == obj.Pkg() {
+			return ""
+		}
+		return p.Path()
+	}
+
+	switch obj := obj.(type) {
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		params := apiSignature(sig, qf)
+		if recv := sig.Recv(); recv != nil {
+			return fmt.Sprintf("method (%s) %s%s", types.TypeString(recv.Type(), qf), obj.Name(), params)
+		}
+		return fmt.Sprintf("func %s%s", obj.Name(), params)
+
+	case *types.TypeName:
+		switch u := obj.Type().Underlying().(type) {
+		case *types.Struct:
+			return fmt.Sprintf("type %s struct", obj.Name())
+		case *types.Interface:
+			return fmt.Sprintf("type %s interface", obj.Name())
+		default:
+			return fmt.Sprintf("type %s %s", obj.Name(), types.TypeString(u, qf))
+		}
+
+	case *types.Const:
+		return fmt.Sprintf("const %s %s", obj.Name(), types.TypeString(obj.Type(), qf))
+
+	case *types.Var:
+		return fmt.Sprintf("var %s %s", obj.Name(), types.TypeString(obj.Type(), qf))
+	}
+
+	return obj.Name()
+}
+
+// apiSignature describes the signature without parameter names, eg. "(int, ...string) error".
+func apiSignature(sig *types.Signature, qf types.Qualifier) string {
+	params := make([]string, sig.Params().Len())
+	for i := range params {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == len(params)-1 {
+			params[i] = "..." + types.TypeString(t.(*types.Slice).Elem(), qf)
+		} else {
+			params[i] = types.TypeString(t, qf)
+		}
+	}
+
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = types.TypeString(sig.Results().At(i).Type(), qf)
+	}
+
+	s := "(" + strings.Join(params, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + results[0]
+	default:
+		return s + " (" + strings.Join(results, ", ") + ")"
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinceRecords(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1.0.0/lib.go": {Data: []byte("package lib\n\nvar X int\n")},
+		"v1.1.0/lib.go": {Data: []byte(`package lib
+
+var X int
+
+type T struct{}
+
+func NewT() *T { return nil }
+
+func (T) M() {}
+`)},
+		"v1.2.0/lib.go": {Data: []byte(`package lib
+
+var X int
+
+type T struct{}
+
+func NewT() *T { return nil }
+
+func (T) M(n int) {}
+`)},
+		"v2.0.0/lib.go": {Data: []byte("package lib\n\nvar X int\n")},
+	}
+
+	versions := []string{"v1.0.0", "v1.1.0", "v1.2.0", "v2.0.0"}
+	records := sinceRecords(versions, func(version string) (map[string]*gompatible.Package, error) {
+		return gompatible.LoadFS(lib, version, "example.com/lib", false)
+	})
+	sortSinceRecords(records)
+
+	got := map[string]sinceRecord{}
+	for _, r := range records {
+		got[r.Name] = sinceRecord{Added: r.Added, Breaking: r.Breaking, Removed: r.Removed}
+	}
+
+	assert.Equal(t, map[string]sinceRecord{
+		"X":    {Added: "v1.0.0"},
+		"T":    {Added: "v1.1.0", Removed: "v2.0.0"},
+		"NewT": {Added: "v1.1.0", Removed: "v2.0.0"},
+		"T.M":  {Added: "v1.1.0", Breaking: "v1.2.0", Removed: "v2.0.0"},
+	}, got)
+
+	var buf bytes.Buffer
+	printSinceAPI(&buf, versions, records)
+	assert.Equal(t, `# v1.0.0
+pkg example.com/lib, var X int
+
+# v1.1.0
+pkg example.com/lib, func NewT() *T
+pkg example.com/lib, type T struct
+pkg example.com/lib, method (T) M()
+
+# v1.2.0
+pkg example.com/lib, method (T) M(int) //breaking
+
+# v2.0.0
+pkg example.com/lib, func NewT() *T //removed
+pkg example.com/lib, type T struct //removed
+pkg example.com/lib, method (T) M(int) //removed
+`, buf.String())
+}
+
+func TestSinceRecordsLoadFailure(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1.0.0/lib.go": {Data: []byte("package lib\n\nvar X int\n")},
+	}
+
+	records := sinceRecords([]string{"v0.9.0", "v1.0.0"}, func(version string) (map[string]*gompatible.Package, error) {
+		if version == "v0.9.0" {
+			return nil, fmt.Errorf("broken")
+		}
+		return gompatible.LoadFS(lib, version, "example.com/lib", false)
+	})
+
+	require.Len(t, records, 1)
+	assert.Equal(t, "v1.0.0", records[0].Added)
+}
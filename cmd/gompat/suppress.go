@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/motemen/gompatible"
+)
+
+// suppression acknowledges an intended breaking change or removal of an API
+// so that it does not make gompat fail.
+type suppression struct {
+	// Package is the package path of the API, which may contain glob patterns.
+	// Empty matches any package.
+	Package string `yaml:"package"`
+	// Symbol is the name of the API eg. "Func", "Type.Method", which may contain glob patterns.
+	Symbol string `yaml:"symbol"`
+	// Kind is "breaking" or "removed". Empty matches both.
+	Kind string `yaml:"kind"`
+	// Justification describes why the change is acceptable.
+	Justification string `yaml:"justification"`
+	// Expires is the date in YYYY-MM-DD after which the suppression is no longer effective.
+	Expires string `yaml:"expires"`
+
+	expires time.Time
+	used    bool
+}
+
+func (s *suppression) validate() error {
+	if s.Symbol == "" {
+		return fmt.Errorf("suppression: symbol must be specified")
+	}
+
+	if _, err := path.Match(s.Package, ""); err != nil {
+		return fmt.Errorf("suppression for %s: bad package pattern: %s", s, err)
+	}
+
+	if _, err := path.Match(s.Symbol, ""); err != nil {
+		return fmt.Errorf("suppression for %s: bad symbol pattern: %s", s, err)
+	}
+
+	switch s.Kind {
+	case "", "breaking", "removed":
+	default:
+		return fmt.Errorf("suppression for %s: unknown kind: %q", s, s.Kind)
+	}
+
+	if s.Expires != "" {
+		t, err := time.ParseInLocation("2006-01-02", s.Expires, time.Local)
+		if err != nil {
+			return fmt.Errorf("suppression for %s: bad expiry date: %s", s, err)
+		}
+		s.expires = t
+	}
+
+	return nil
+}
+
+func (s *suppression) String() string {
+	if s.Package == "" {
+		return s.Symbol
+	}
+	return s.Package + "." + s.Symbol
+}
+
+// expired reports whether the suppression has been expired at now.
+func (s *suppression) expired(now time.Time) bool {
+	// effective through the day of expiry
+	return s.expires.IsZero() == false && now.After(s.expires.AddDate(0, 0, 1))
+}
+
+func (s *suppression) matches(e changeEntry) bool {
+	switch e.Change.Kind() {
+	case gompatible.ChangeBreaking:
+		if s.Kind != "" && s.Kind != "breaking" {
+			return false
+		}
+	case gompatible.ChangeRemoved:
+		if s.Kind != "" && s.Kind != "removed" {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if s.Package != "" {
+		if ok, _ := path.Match(s.Package, e.Package); !ok {
+			return false
+		}
+	}
+
+	ok, _ := path.Match(s.Symbol, e.Name)
+	return ok
+}
+
+// applySuppressions marks the entries matching any of the suppressions as acknowledged
+// and returns warnings for expired or unused suppressions.
+func applySuppressions(entries []changeEntry, sups []*suppression, now time.Time) []string {
+	warnings := []string{}
+
+	for i, e := range entries {
+		// All the matching suppressions are used, though the first effective one acknowledges the change
+		for _, s := range sups {
+			if s.matches(e) == false {
+				continue
+			}
+
+			s.used = true
+
+			if s.expired(now) || entries[i].Acknowledged != nil {
+				continue
+			}
+
+			entries[i].Acknowledged = s
+		}
+	}
+
+	for _, s := range sups {
+		switch {
+		case s.expired(now):
+			warnings = append(warnings, fmt.Sprintf("suppression for %s has expired on %s", s, s.Expires))
+		case s.used == false:
+			warnings = append(warnings, fmt.Sprintf("suppression for %s matched no changes", s))
+		}
+	}
+
+	return warnings
+}
+
+// acknowledgeExperimental marks breaking changes and removals of the APIs
+// marked experimental by the "//gompat:experimental" directive as acknowledged.
+func acknowledgeExperimental(entries []changeEntry) {
+	for i, e := range entries {
+		if e.Acknowledged != nil || gompatible.HasDirective(e.Change, gompatible.DirectiveExperimental) == false {
+			continue
+		}
+
+		kind := e.Change.Kind()
+		if kind == gompatible.ChangeBreaking || kind == gompatible.ChangeRemoved {
+			entries[i].Acknowledged = &suppression{
+				Package:       e.Package,
+				Symbol:        e.Name,
+				Justification: "the API is marked experimental",
+			}
+		}
+	}
+}
+
+func acknowledgedNote(s *suppression) string {
+	note := "acknowledged"
+	if s.Justification != "" {
+		note += ": " + strings.TrimSpace(s.Justification)
+	}
+	return note
+}
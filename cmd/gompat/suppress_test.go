@@ -0,0 +1,193 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuppressionMatches(t *testing.T) {
+	entries := testdataEntries(t)
+
+	tests := []struct {
+		sup     suppression
+		name    string
+		matches bool
+	}{
+		{suppression{Symbol: "Breaking1"}, "Breaking1", true},
+		{suppression{Symbol: "Breaking*"}, "Breaking2", true},
+		{suppression{Symbol: "Breaking1", Kind: "breaking"}, "Breaking1", true},
+		{suppression{Symbol: "Breaking1", Kind: "removed"}, "Breaking1", false},
+		{suppression{Symbol: "Removed1", Kind: "removed"}, "Removed1", true},
+		{suppression{Symbol: "Removed1", Kind: "breaking"}, "Removed1", false},
+		{suppression{Symbol: "Breaking1", Package: "test*"}, "Breaking1", true},
+		{suppression{Symbol: "Breaking1", Package: "example.com/*"}, "Breaking1", false},
+		{suppression{Symbol: "Breaking2"}, "Breaking1", false},
+		// Only breaking changes and removals are acknowledged
+		{suppression{Symbol: "*"}, "Compatible1", false},
+		{suppression{Symbol: "*"}, "Added1", false},
+		{suppression{Symbol: "*"}, "Unchanged1", false},
+	}
+
+	for _, test := range tests {
+		e, ok := entries[test.name]
+		require.True(t, ok, test.name)
+		assert.Equal(t, test.matches, test.sup.matches(e), "%s %+v", test.name, test.sup)
+	}
+}
+
+func TestSuppressionExpired(t *testing.T) {
+	tests := []struct {
+		expires string
+		now     string
+		expired bool
+	}{
+		{"", "2100-01-01", false},
+		{"2024-03-01", "2024-02-29", false},
+		// effective through the day of expiry
+		{"2024-03-01", "2024-03-01", false},
+		{"2024-03-01", "2024-03-02", true},
+	}
+
+	for _, test := range tests {
+		s := &suppression{Symbol: "F", Expires: test.expires}
+		require.NoError(t, s.validate())
+
+		now, err := time.ParseInLocation("2006-01-02", test.now, time.Local)
+		require.NoError(t, err)
+		now = now.Add(12 * time.Hour)
+
+		assert.Equal(t, test.expired, s.expired(now), "%s at %s", test.expires, test.now)
+	}
+}
+
+func TestApplySuppressions(t *testing.T) {
+	all := testdataEntries(t)
+	entries := []changeEntry{all["Breaking1"], all["Breaking2"], all["Removed1"], all["Added1"]}
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	sups := []*suppression{
+		{Symbol: "Breaking1", Justification: "intended"},
+		{Symbol: "Removed1", Expires: "2024-02-01"},
+		{Symbol: "Gone"},
+	}
+	for _, s := range sups {
+		require.NoError(t, s.validate())
+	}
+
+	warnings := applySuppressions(entries, sups, now)
+
+	assert.Equal(t, sups[0], entries[0].Acknowledged)
+	assert.Nil(t, entries[1].Acknowledged)
+	// Expired suppressions do not acknowledge changes
+	assert.Nil(t, entries[2].Acknowledged)
+	assert.Nil(t, entries[3].Acknowledged)
+
+	assert.Equal(t, []string{
+		"suppression for Removed1 has expired on 2024-02-01",
+		"suppression for Gone matched no changes",
+	}, warnings)
+}
+
+func TestApplySuppressionsEdgeCases(t *testing.T) {
+	all := testdataEntries(t)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		entry    string
+		sups     []*suppression
+		acked    int // index of the suppression acknowledging the entry, or -1
+		warnings []string
+	}{
+		{
+			name:  "an expired suppression falls through to the next one",
+			entry: "Breaking1",
+			sups: []*suppression{
+				{Symbol: "Breaking1", Expires: "2024-01-31"},
+				{Symbol: "Breaking*"},
+			},
+			acked:    1,
+			warnings: []string{"suppression for Breaking1 has expired on 2024-01-31"},
+		},
+		{
+			name:  "the first matching suppression wins",
+			entry: "Breaking1",
+			sups: []*suppression{
+				{Symbol: "Breaking1", Justification: "first"},
+				{Symbol: "Breaking*", Justification: "second"},
+			},
+			acked:    0,
+			warnings: []string{},
+		},
+		{
+			name:     "an expired suppression matching nothing is reported as expired",
+			entry:    "Breaking1",
+			sups:     []*suppression{{Symbol: "Gone", Expires: "2024-01-31"}},
+			acked:    -1,
+			warnings: []string{"suppression for Gone has expired on 2024-01-31"},
+		},
+		{
+			name:     "suppressions of added APIs match nothing",
+			entry:    "Added1",
+			sups:     []*suppression{{Symbol: "Added1"}},
+			acked:    -1,
+			warnings: []string{"suppression for Added1 matched no changes"},
+		},
+		{
+			name:     "suppressions of other packages match nothing",
+			entry:    "Breaking1",
+			sups:     []*suppression{{Symbol: "Breaking1", Package: "example.com/*"}},
+			acked:    -1,
+			warnings: []string{"suppression for example.com/*.Breaking1 matched no changes"},
+		},
+		{
+			name:     "no suppressions",
+			entry:    "Breaking1",
+			acked:    -1,
+			warnings: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		for _, s := range test.sups {
+			require.NoError(t, s.validate(), test.name)
+		}
+
+		entries := []changeEntry{all[test.entry]}
+		warnings := applySuppressions(entries, test.sups, now)
+
+		if test.acked < 0 {
+			assert.Nil(t, entries[0].Acknowledged, test.name)
+		} else {
+			assert.Equal(t, test.sups[test.acked], entries[0].Acknowledged, test.name)
+		}
+		assert.Equal(t, test.warnings, warnings, test.name)
+	}
+}
+
+func TestSuppressionValidate(t *testing.T) {
+	tests := []struct {
+		sup suppression
+		err bool
+	}{
+		{suppression{Symbol: "F"}, false},
+		{suppression{Symbol: "T.*", Package: "example.com/*", Kind: "removed", Expires: "2024-01-31"}, false},
+		{suppression{}, true},
+		{suppression{Symbol: "["}, true},
+		{suppression{Symbol: "F", Package: "["}, true},
+		{suppression{Symbol: "F", Kind: "added"}, true},
+		{suppression{Symbol: "F", Expires: "01/31/2024"}, true},
+	}
+
+	for _, test := range tests {
+		err := test.sup.validate()
+		if test.err {
+			assert.Error(t, err, "%+v", test.sup)
+		} else {
+			assert.NoError(t, err, "%+v", test.sup)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"go/token"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// templateData is the data passed to user-defined templates specified by -template.
+type templateData struct {
+	Packages []*templatePackage
+	Changes  []templateChange
+}
+
+type templatePackage struct {
+	Path    string
+	Diff    gompatible.PackageChanges
+	Changes []templateChange
+}
+
+type templateChange struct {
+	Package   string
+	Name      string
+	Category  gompatible.ObjectCategory
+	Kind      gompatible.ChangeKind
+	Change    gompatible.Change
+	Before    string
+	After     string
+	Reasons   []string
+	PosBefore token.Position
+	PosAfter  token.Position
+
+	// Justification is set when the change is acknowledged by a suppression
+	Acknowledged  bool
+	Justification string
+}
+
+var templateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"lower":      strings.ToLower,
+	"showChange": gompatible.ShowChange,
+}
+
+func parseTemplateFile(file string) (*template.Template, error) {
+	return template.New(filepath.Base(file)).Funcs(templateFuncs).ParseFiles(file)
+}
+
+// printTemplate renders entries, the changes shown, by tmpl. Packages are all the ones in diffs
+// even if none of their changes are shown, so templates can summarize the whole comparison.
+func printTemplate(w io.Writer, tmpl *template.Template, diffs map[string]gompatible.PackageChanges, entries []changeEntry) error {
+	data := templateData{
+		Packages: []*templatePackage{},
+		Changes:  make([]templateChange, len(entries)),
+	}
+
+	pkgIndex := map[string]*templatePackage{}
+	for _, path := range util.SortedStringSet(util.MapKeys(diffs)) {
+		pkg := &templatePackage{
+			Path: path,
+			Diff: diffs[path],
+		}
+		pkgIndex[path] = pkg
+		data.Packages = append(data.Packages, pkg)
+	}
+
+	for i, e := range entries {
+		c := templateChange{
+			Package:   e.Package,
+			Name:      e.Name,
+			Category:  e.Category,
+			Kind:      e.Change.Kind(),
+			Change:    e.Change,
+			Before:    e.Change.ShowBefore(),
+			After:     e.Change.ShowAfter(),
+			Reasons:   gompatible.Reasons(e.Change),
+			PosBefore: e.Change.PosBefore(),
+			PosAfter:  e.Change.PosAfter(),
+		}
+		if e.Acknowledged != nil {
+			c.Acknowledged = true
+			c.Justification = e.Acknowledged.Justification
+		}
+		data.Changes[i] = c
+
+		pkg, ok := pkgIndex[e.Package]
+		if !ok {
+			pkg = &templatePackage{
+				Path: e.Package,
+				Diff: diffs[e.Package],
+			}
+			pkgIndex[e.Package] = pkg
+			data.Packages = append(data.Packages, pkg)
+		}
+		pkg.Changes = append(pkg.Changes, c)
+	}
+
+	return tmpl.Execute(w, data)
+}
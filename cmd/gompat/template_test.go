@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/motemen/gompatible"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintTemplate(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go":     {Data: []byte("package lib\n\nfunc F() {}\n\nfunc G() {}\n")},
+		"v1/sub/sub.go": {Data: []byte("package sub\n\nfunc S() {}\n")},
+		"v2/lib.go":     {Data: []byte("package lib\n\nfunc F(n int) {}\n\nfunc G() {}\n\nfunc H() {}\n")},
+		"v2/sub/sub.go": {Data: []byte("package sub\n\nfunc S() {}\n")},
+	}
+
+	pkgs1, err := gompatible.LoadFS(lib, "v1", "example.com/lib", true)
+	require.NoError(t, err)
+	pkgs2, err := gompatible.LoadFS(lib, "v2", "example.com/lib", true)
+	require.NoError(t, err)
+
+	diffs := diffPackageSets(pkgs1, pkgs2)
+	entries := filterChanges(listChanges(diffs), func(e changeEntry) bool {
+		return e.Change.Kind() != gompatible.ChangeUnchanged
+	})
+
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	render := func(name, text string) string {
+		file := filepath.Join(tempDir, name)
+		require.NoError(t, ioutil.WriteFile(file, []byte(text), 0644))
+
+		tmpl, err := parseTemplateFile(file)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, printTemplate(&buf, tmpl, diffs, entries))
+		return buf.String()
+	}
+
+	// The example in README
+	assert.Equal(t, `- [Breaking] example.com/lib.F: parameter #1 added: int
+- [Added] example.com/lib.H
+`, render("readme.tmpl", `{{range .Changes}}- [{{.Kind}}] {{.Package}}.{{.Name}}{{if .Reasons}}: {{join .Reasons ", "}}{{end}}
+{{end}}`))
+
+	// Packages without changes shown are listed too
+	assert.Equal(t, `example.com/lib: 2 shown
+  F v1/lib.go:3:6 -> v2/lib.go:3:6 func F(n int)
+  H - -> v2/lib.go:7:6 func H()
+example.com/lib/sub: 0 shown
+`, render("custom.tmpl", `{{range .Packages}}{{.Path}}: {{len .Changes}} shown
+{{range .Changes}}  {{.Name}} {{.PosBefore}} -> {{.PosAfter}} {{.After}}
+{{end}}{{end}}`))
+}
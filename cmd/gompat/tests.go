@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// printTestFailures type-checks the external tests of the packages before the changes against
+// the ones after the changes and prints the errors with the changes they are attributed to.
+// It returns the number of the errors.
+func printTestFailures(w io.Writer, diffs map[string]gompatible.PackageChanges) int {
+	var n int
+
+	for _, pkgName := range util.SortedStringSet(util.MapKeys(diffs)) {
+		for _, f := range gompatible.CheckTests(diffs[pkgName]) {
+			n++
+
+			if f.Change == nil {
+				fmt.Fprintf(w, "%s: %s\n", pkgName, f)
+				continue
+			}
+
+			kind := strings.ToLower(f.Change.Kind().String())
+			fmt.Fprintf(w, "%s.%s (%s): %s\n", pkgName, f.Name, kind, f)
+		}
+	}
+
+	return n
+}
@@ -7,8 +7,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 
 	"go/types"
+
+	"github.com/motemen/gompatible"
+
+	"golang.org/x/mod/semver"
 )
 
 func dieIf(err error) {
@@ -19,6 +25,84 @@ func dieIf(err error) {
 	}
 }
 
+// requireGit returns an error if the repository which dir belongs to is not of git,
+// as what, eg. the name of the subcommand, runs git commands.
+func requireGit(dir, what string) error {
+	backend, _, err := gompatible.DetectVCS(dir)
+	if err != nil {
+		return err
+	}
+
+	if backend.Name() != "git" {
+		return fmt.Errorf("%s is supported only in git repositories, not %s", what, backend.Name())
+	}
+
+	return nil
+}
+
+// semverTags returns the semantic version tags of the git repository
+// which the dir belongs to, in ascending order.
+func semverTags(dir string) ([]string, error) {
+	cmd := exec.Command("git", "tag", "--list")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	for _, tag := range strings.Split(string(out), "\n") {
+		if semver.IsValid(tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return semver.Compare(tags[i], tags[j]) < 0
+	})
+
+	return tags, nil
+}
+
+// gitMergeBase returns the best common ancestor of two revisions.
+func gitMergeBase(dir, rev1, rev2 string) (string, error) {
+	cmd := exec.Command("git", "merge-base", rev1, rev2)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git merge-base %s %s: %s", rev1, rev2, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitDefaultBranch guesses the default branch of the repository,
+// by the HEAD of the remote "origin" or by the existence of "main" or "master" branches.
+func gitDefaultBranch(dir string) (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	cmd.Dir = dir
+
+	if out, err := cmd.Output(); err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	for _, branch := range []string{"main", "master"} {
+		cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+		cmd.Dir = dir
+		if err := cmd.Run(); err == nil {
+			return branch, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not determine the default branch")
+}
+
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
+}
+
 func diff(a, b []byte) ([]byte, error) {
 	f1, err := ioutil.TempFile("", "gompat")
 	if err != nil {
@@ -74,3 +158,51 @@ func typesObjectString(obj types.Object) string {
 
 	return prefix + " " + obj.Name()
 }
+
+// gitCommit is a commit listed by gitLog.
+type gitCommit struct {
+	Hash      string
+	ShortHash string
+	Parents   []string
+	Author    string
+	Date      string
+	Subject   string
+}
+
+// gitLog lists the commits reachable from rev2 but not from rev1, or all the ones
+// reachable from rev2 if rev1 is empty, parents first.
+// opts are passed to git log after the revisions, eg. "--first-parent" or "--", "<path>".
+func gitLog(dir, rev1, rev2 string, opts ...string) ([]gitCommit, error) {
+	revs := rev1 + ".." + rev2
+	if rev1 == "" {
+		revs = rev2
+	}
+
+	args := []string{"log", "--reverse", "--topo-order", "--date=short", "--format=%H%x00%h%x00%P%x00%an%x00%ad%x00%s", revs}
+	cmd := exec.Command("git", append(args, opts...)...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %s", revs, err)
+	}
+
+	commits := []gitCommit{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.SplitN(line, "\x00", 6)
+		if len(fields) != 6 {
+			continue
+		}
+
+		commits = append(commits, gitCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Parents:   strings.Fields(fields[2]),
+			Author:    fields[3],
+			Date:      fields[4],
+			Subject:   fields[5],
+		})
+	}
+
+	return commits, nil
+}
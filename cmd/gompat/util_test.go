@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireGit(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	for _, name := range []string{"git", "hg"} {
+		if _, err := exec.LookPath(name); err != nil {
+			continue
+		}
+
+		dir := filepath.Join(tempDir, name)
+		require.NoError(t, os.Mkdir(dir, 0755))
+
+		cmd := exec.Command(name, "init")
+		cmd.Dir = dir
+		require.NoError(t, cmd.Run())
+
+		err := requireGit(dir, "log")
+		if name == "git" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, "log is supported only in git repositories, not hg")
+		}
+	}
+}
+
+func TestRevisionRangesOfGit(t *testing.T) {
+	repo, git := gitRepo(t)
+	defer os.RemoveAll(repo)
+
+	commit := func(message string) string {
+		writeFiles(t, repo, map[string]string{"lib.go": "package lib\n\n// " + message + "\n"})
+		git("add", ".")
+		git("commit", "-q", "-m", message)
+		return git("rev-parse", "HEAD")
+	}
+
+	// A---B main
+	//  \
+	//   C feature
+	a := commit("A")
+	commit("B")
+	git("checkout", "-q", "-b", "feature", a)
+	c := commit("C")
+
+	base, err := gitMergeBase(repo, "main", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, a, base)
+
+	_, err = gitMergeBase(repo, "main", "bogus")
+	assert.Error(t, err)
+
+	tests := []struct {
+		spec       string
+		rev1, rev2 string
+		err        bool
+	}{
+		{spec: "main...feature", rev1: a, rev2: "feature"},
+		{spec: "main...", rev1: a, rev2: "HEAD"},
+		{spec: "feature...main", rev1: a, rev2: "main"},
+		{spec: "main...bogus", err: true},
+		// Not merge base ranges
+		{spec: "main..feature", rev1: "main", rev2: "feature"},
+		{spec: "main", rev1: "main~1", rev2: "main"},
+	}
+
+	for _, test := range tests {
+		rev1, rev2, err := resolveRevisionRange(test.spec, repo)
+		if test.err {
+			assert.Error(t, err, test.spec)
+			continue
+		}
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, [2]string{test.rev1, test.rev2}, [2]string{rev1, rev2}, test.spec)
+	}
+
+	branch, err := gitDefaultBranch(repo)
+	require.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	// -base compares HEAD, or the given revision, with the merge base
+	rev1, rev2, err := baseRevisionRange(repo, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{a, "HEAD"}, [2]string{rev1, rev2})
+
+	git("checkout", "-q", "main")
+	rev1, rev2, err = baseRevisionRange(repo, c)
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{a, c}, [2]string{rev1, rev2})
+
+	// The HEAD of origin precedes the local branches
+	git("update-ref", "refs/remotes/origin/feature", c)
+	git("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/feature")
+	branch, err = gitDefaultBranch(repo)
+	require.NoError(t, err)
+	assert.Equal(t, "origin/feature", branch)
+
+	rev1, rev2, err = baseRevisionRange(repo, "main")
+	require.NoError(t, err)
+	assert.Equal(t, [2]string{a, "main"}, [2]string{rev1, rev2})
+}
+
+func TestGitDefaultBranchMissing(t *testing.T) {
+	repo, git := gitRepo(t)
+	defer os.RemoveAll(repo)
+
+	writeFiles(t, repo, map[string]string{"lib.go": "package lib\n"})
+	git("add", ".")
+	git("commit", "-q", "-m", "A")
+	git("branch", "-q", "-m", "trunk")
+
+	_, err := gitDefaultBranch(repo)
+	assert.EqualError(t, err, "could not determine the default branch")
+
+	_, _, err = baseRevisionRange(repo, "HEAD")
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/motemen/gompatible"
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// printVerifications verifies the classifications of the changes and prints the ones
+// contradicted by the client programs, with the programs if verbose. It returns the number of them.
+func printVerifications(w io.Writer, diffs map[string]gompatible.PackageChanges, verbose bool) int {
+	var mismatches, inconclusive int
+
+	for _, pkgName := range util.SortedStringSet(util.MapKeys(diffs)) {
+		for _, v := range gompatible.Verify(diffs[pkgName]) {
+			if v.Inconclusive() {
+				inconclusive++
+				continue
+			}
+
+			if v.Mismatch() == false {
+				continue
+			}
+			mismatches++
+
+			kind := strings.ToLower(v.Kind.String())
+			if v.ErrAfter == nil {
+				fmt.Fprintf(w, "%s.%s: classified as %s, but a client compiles after the change\n", pkgName, v.Name, kind)
+			} else {
+				fmt.Fprintf(w, "%s.%s: classified as %s, but a client does not compile after the change: %s\n", pkgName, v.Name, kind, v.ErrAfter)
+			}
+
+			if verbose {
+				for _, line := range strings.Split(strings.TrimSpace(v.Client), "\n") {
+					fmt.Fprintf(w, "  %s\n", line)
+				}
+			}
+		}
+	}
+
+	if inconclusive > 0 {
+		warnf("%d changes could not be verified", inconclusive)
+	}
+
+	return mismatches
+}
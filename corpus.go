@@ -0,0 +1,184 @@
+package gompatible
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// CorpusUsage is how widely an API is used in a corpus of modules.
+type CorpusUsage struct {
+	// References is the number of the references to the API
+	References int
+	// Modules is the number of the modules referring to the API
+	Modules int
+}
+
+// ScanCorpus counts the references to the package-level APIs of the package, which is
+// imported by importPath and named pkgName, in the modules in the module cache modCache,
+// eg. $GOMODCACHE. Only the latest version of each module is scanned, and the module
+// containing the package is skipped. References are found syntactically as selectors
+// qualified by the imports of the package, so the uses of methods and fields are not counted.
+// The result is keyed by the names of the APIs.
+func ScanCorpus(modCache, importPath, pkgName string) (map[string]*CorpusUsage, error) {
+	roots, err := corpusModules(modCache)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := map[string]*CorpusUsage{}
+	for modPath, root := range roots {
+		if importPath == modPath || strings.HasPrefix(importPath, modPath+"/") {
+			continue
+		}
+
+		refs, err := countReferences(root, importPath, pkgName)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, n := range refs {
+			u := usages[name]
+			if u == nil {
+				u = &CorpusUsage{}
+				usages[name] = u
+			}
+			u.References += n
+			u.Modules++
+		}
+	}
+
+	return usages, nil
+}
+
+// corpusModules returns the root directories of the latest versions of the modules in the module cache,
+// keyed by the module paths.
+func corpusModules(modCache string) (map[string]string, error) {
+	roots := map[string]string{}
+	versions := map[string]string{}
+
+	err := filepath.WalkDir(modCache, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() == false {
+			return nil
+		}
+
+		rel, err := filepath.Rel(modCache, p)
+		if err != nil {
+			return err
+		}
+		if rel == "cache" {
+			// Downloaded archives
+			return filepath.SkipDir
+		}
+
+		at := strings.LastIndex(d.Name(), "@")
+		if at == -1 {
+			return nil
+		}
+
+		escPath, version := filepath.ToSlash(rel[:len(rel)-len(d.Name())+at]), d.Name()[at+1:]
+		modPath, err := module.UnescapePath(escPath)
+		if err != nil {
+			return filepath.SkipDir
+		}
+
+		if v, ok := versions[modPath]; !ok || semver.Compare(version, v) > 0 {
+			versions[modPath] = version
+			roots[modPath] = p
+		}
+
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return roots, nil
+}
+
+// countReferences counts the references to the package-level APIs of the package in the Go files under root.
+func countReferences(root, importPath, pkgName string) (map[string]int, error) {
+	refs := map[string]int{}
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if name := d.Name(); p != root && (name[0] == '.' || name[0] == '_' || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(p, ".go") == false {
+			return nil
+		}
+
+		// Read the imports first not to parse the whole of the files irrelevant
+		f, err := parser.ParseFile(fset, p, nil, parser.ImportsOnly)
+		if err != nil {
+			return nil
+		}
+
+		local := importName(f, importPath, pkgName)
+		if local == "" {
+			return nil
+		}
+
+		f, err = parser.ParseFile(fset, p, nil, 0)
+		if err != nil {
+			return nil
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				// Unresolved identifiers may be the package names
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == local && x.Obj == nil {
+					refs[sel.Sel.Name]++
+				}
+			}
+			return true
+		})
+
+		return nil
+	})
+
+	return refs, err
+}
+
+// importName returns the name the file refers to the package by, or an empty string
+// if the file does not import the package or imports it with a blank or dot name.
+func importName(f *ast.File, importPath, pkgName string) string {
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err != nil || p != importPath {
+			continue
+		}
+
+		if imp.Name == nil {
+			return pkgName
+		}
+
+		switch imp.Name.Name {
+		case "_", ".":
+			return ""
+		default:
+			return imp.Name.Name
+		}
+	}
+
+	return ""
+}
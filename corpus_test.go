@@ -0,0 +1,42 @@
+package gompatible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanCorpus(t *testing.T) {
+	modCache, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(modCache)
+
+	for name, content := range map[string]string{
+		"example.com/lib@v1.0.0/lib.go":          "package lib\n\nfunc F() { F() }\n",
+		"example.com/!user@v1.0.0/a.go":          "package user\n\nimport \"example.com/lib\"\n\nfunc A() { lib.F(); lib.F(); lib.G() }\n",
+		"example.com/!user@v1.1.0/a.go":          "package user\n\nimport l \"example.com/lib\"\n\nfunc A() { l.F() }\n",
+		"example.com/!user@v1.1.0/sub/b.go":      "package sub\n\nimport \"example.com/lib\"\n\nfunc B(lib struct{ F int }) { _ = lib.F }\n",
+		"example.com/other@v0.1.0/c.go":          "package other\n\nimport \"example.com/lib\"\n\nvar _ = lib.F\n",
+		"example.com/other@v0.1.0/testdata/d.go": "package d\n\nimport \"example.com/lib\"\n\nvar _ = lib.F\n",
+		"example.com/unrelated@v0.1.0/e.go":      "package unrelated\n\nfunc F() {}\n",
+		"cache/download/example.com/lib/@v/list": "v1.0.0\n",
+		"example.com/blank@v0.1.0/f.go":          "package blank\n\nimport _ \"example.com/lib\"\n",
+		"example.com/broken@v0.1.0/broken.go":    "package broken\n\nimport \"example.com/lib\"\n\nfunc {",
+		"example.com/lib/sub@v1.0.0/sub.go":      "package sub\n\nimport \"example.com/lib\"\n\nvar _ = lib.F\n",
+	} {
+		name = filepath.Join(modCache, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	usages, err := ScanCorpus(modCache, "example.com/lib", "lib")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]*CorpusUsage{
+		"F": {References: 3, Modules: 3},
+	}, usages)
+}
@@ -0,0 +1,45 @@
+package gompatible
+
+import (
+	"strings"
+)
+
+// isDeprecated reports whether the doc comment has a paragraph starting with "Deprecated: ",
+// which is the convention to mark APIs deprecated.
+func isDeprecated(doc string) bool {
+	for _, para := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(strings.TrimSpace(para), "Deprecated: ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Deprecated reports whether the function is documented as deprecated.
+func (f *Func) Deprecated() bool {
+	return f != nil && f.Doc != nil && isDeprecated(f.Doc.Doc)
+}
+
+// Deprecated reports whether the type is documented as deprecated.
+func (t *Type) Deprecated() bool {
+	return t != nil && t.Doc != nil && isDeprecated(t.Doc.Doc)
+}
+
+// Deprecated reports whether the value (or the declaration group it belongs to) is documented as deprecated.
+func (v *Value) Deprecated() bool {
+	return v != nil && v.Doc != nil && isDeprecated(v.Doc.Doc)
+}
+
+// Deprecation reports whether the API was documented as deprecated before and after the change.
+func Deprecation(c Change) (before, after bool) {
+	switch c := c.(type) {
+	case FuncChange:
+		return c.Before.Deprecated(), c.After.Deprecated()
+	case TypeChange:
+		return c.Before.Deprecated(), c.After.Deprecated()
+	case ValueChange:
+		return c.Before.Deprecated(), c.After.Deprecated()
+	}
+
+	return false, false
+}
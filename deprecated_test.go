@@ -0,0 +1,29 @@
+package gompatible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecation(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	before, after := Deprecation(diff.Funcs()["Unchanged4"])
+	assert.False(t, before)
+	assert.True(t, after)
+
+	before, after = Deprecation(diff.Funcs()["Unchanged1"])
+	assert.False(t, before)
+	assert.False(t, after)
+
+	before, after = Deprecation(diff.Funcs()["Added1"])
+	assert.False(t, before)
+	assert.False(t, after)
+}
@@ -60,6 +60,8 @@ func (pc PackageChanges) Values() map[string]ValueChange {
 }
 
 // DiffPackages takes two packages to produce the changes between them.
+// Either of the packages may be nil, when the package is added or removed.
+// APIs marked by the "//gompat:ignore" directive are excluded.
 func DiffPackages(pkg1, pkg2 *Package) PackageChanges {
 	diff := PackageChanges{
 		Before: pkg1,
@@ -71,46 +73,73 @@ func DiffPackages(pkg1, pkg2 *Package) PackageChanges {
 		},
 	}
 
+	if pkg1 == nil {
+		pkg1 = &Package{}
+	}
+	if pkg2 == nil {
+		pkg2 = &Package{}
+	}
+
 	for _, name := range util.SortedStringSet(util.MapKeys(pkg1.Funcs), util.MapKeys(pkg2.Funcs)) {
 		Debugf("%q", name)
-		diff.Changes[ObjectCategoryFunc][name] = FuncChange{
+		change := FuncChange{
 			Before: pkg1.Funcs[name],
 			After:  pkg2.Funcs[name],
 		}
+		if HasDirective(change, DirectiveIgnore) {
+			continue
+		}
+		diff.Changes[ObjectCategoryFunc][name] = change
 	}
 
 	for _, name := range util.SortedStringSet(util.MapKeys(pkg1.Types), util.MapKeys(pkg2.Types)) {
 		type1 := pkg1.Types[name]
 		type2 := pkg2.Types[name]
 
-		diff.Changes[ObjectCategoryType][name] = TypeChange{
+		change := TypeChange{
 			Before: pkg1.Types[name],
 			After:  pkg2.Types[name],
 		}
+		if HasDirective(change, DirectiveIgnore) {
+			continue
+		}
+		diff.Changes[ObjectCategoryType][name] = change
 
 		if type1 != nil && type2 != nil {
 			for _, fname := range util.SortedStringSet(util.MapKeys(type1.Funcs), util.MapKeys(type2.Funcs)) {
-				diff.Changes[ObjectCategoryFunc][fname] = FuncChange{
+				change := FuncChange{
 					Before: type1.Funcs[fname],
 					After:  type2.Funcs[fname],
 				}
+				if HasDirective(change, DirectiveIgnore) {
+					continue
+				}
+				diff.Changes[ObjectCategoryFunc][fname] = change
 			}
 
 			for _, mname := range util.SortedStringSet(util.MapKeys(type1.Methods), util.MapKeys(type2.Methods)) {
-				diff.Changes[ObjectCategoryFunc][name+"."+mname] = FuncChange{
+				change := FuncChange{
 					Before: type1.Methods[mname],
 					After:  type2.Methods[mname],
 				}
+				if HasDirective(change, DirectiveIgnore) {
+					continue
+				}
+				diff.Changes[ObjectCategoryFunc][name+"."+mname] = change
 			}
 		}
 	}
 
 	for _, name := range util.SortedStringSet(util.MapKeys(pkg1.Values), util.MapKeys(pkg2.Values)) {
 		Debugf("%q", name)
-		diff.Changes[ObjectCategoryValue][name] = ValueChange{
+		change := ValueChange{
 			Before: pkg1.Values[name],
 			After:  pkg2.Values[name],
 		}
+		if HasDirective(change, DirectiveIgnore) {
+			continue
+		}
+		diff.Changes[ObjectCategoryValue][name] = change
 	}
 
 	return diff
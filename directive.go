@@ -0,0 +1,198 @@
+package gompatible
+
+import (
+	"strings"
+
+	"go/ast"
+)
+
+// Directive is a comment directive of form "//gompat:<name>" put on a declaration
+// to tell how gompatible should treat the API.
+type Directive string
+
+const (
+	// DirectiveIgnore excludes the API from the comparison.
+	DirectiveIgnore Directive = "ignore"
+	// DirectiveExperimental marks the API experimental, whose breaking changes are acceptable.
+	DirectiveExperimental Directive = "experimental"
+	// DirectiveStable marks the API stable, whose compatible changes are also considered breaking.
+	DirectiveStable Directive = "stable"
+)
+
+const directivePrefix = "//gompat:"
+
+// parseDirectives extracts directives from the comment group.
+func parseDirectives(cg *ast.CommentGroup) []Directive {
+	if cg == nil {
+		return nil
+	}
+
+	var directives []Directive
+	for _, c := range cg.List {
+		if !strings.HasPrefix(c.Text, directivePrefix) {
+			continue
+		}
+
+		if fields := strings.Fields(strings.TrimPrefix(c.Text, directivePrefix)); len(fields) > 0 {
+			directives = append(directives, Directive(fields[0]))
+		}
+	}
+
+	return directives
+}
+
+// collectDirectives collects directives of the declarations in the files, keyed by
+// the names of APIs in the same manner as PackageChanges do (eg. "Func", "Type.Method").
+// This must be called before doc.New, which removes doc comments from the AST.
+func collectDirectives(files map[string]*ast.File) map[string][]Directive {
+	directives := map[string][]Directive{}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				name := decl.Name.Name
+				if decl.Recv != nil && len(decl.Recv.List) > 0 {
+					if recv := recvTypeName(decl.Recv.List[0].Type); recv != "" {
+						name = recv + "." + name
+					}
+				}
+				directives[name] = append(directives[name], parseDirectives(decl.Doc)...)
+
+			case *ast.GenDecl:
+				declDirectives := parseDirectives(decl.Doc)
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						name := spec.Name.Name
+						directives[name] = append(directives[name], declDirectives...)
+						directives[name] = append(directives[name], parseDirectives(spec.Doc)...)
+
+					case *ast.ValueSpec:
+						for _, ident := range spec.Names {
+							name := ident.Name
+							directives[name] = append(directives[name], declDirectives...)
+							directives[name] = append(directives[name], parseDirectives(spec.Doc)...)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return directives
+}
+
+// recvTypeName returns the name of the receiver type eg. "T" of "*T" or "T[K, V]".
+func recvTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexExpr:
+		return recvTypeName(expr.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(expr.X)
+	case *ast.ParenExpr:
+		return recvTypeName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+
+	return ""
+}
+
+func hasDirective(directives []Directive, d Directive) bool {
+	for _, dd := range directives {
+		if dd == d {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDirectives sets directives to the APIs of the package.
+func (p *Package) applyDirectives(directives map[string][]Directive) {
+	for name, f := range p.Funcs {
+		f.Directives = directives[name]
+	}
+
+	for name, t := range p.Types {
+		t.Directives = directives[name]
+		for fname, f := range t.Funcs {
+			f.Directives = directives[fname]
+		}
+		for mname, m := range t.Methods {
+			m.Directives = directives[name+"."+mname]
+		}
+	}
+
+	for name, v := range p.Values {
+		v.Directives = directives[name]
+	}
+}
+
+// HasDirective reports whether the function has the directive.
+func (f *Func) HasDirective(d Directive) bool {
+	return f != nil && hasDirective(f.Directives, d)
+}
+
+// HasDirective reports whether the type has the directive.
+func (t *Type) HasDirective(d Directive) bool {
+	return t != nil && hasDirective(t.Directives, d)
+}
+
+// HasDirective reports whether the value has the directive.
+func (v *Value) HasDirective(d Directive) bool {
+	return v != nil && hasDirective(v.Directives, d)
+}
+
+// HasDirective reports whether the API has the directive in either of the revisions.
+func HasDirective(c Change, d Directive) bool {
+	switch c := c.(type) {
+	case FuncChange:
+		return c.Before.HasDirective(d) || c.After.HasDirective(d)
+	case TypeChange:
+		return c.Before.HasDirective(d) || c.After.HasDirective(d)
+	case ValueChange:
+		return c.Before.HasDirective(d) || c.After.HasDirective(d)
+	}
+
+	return false
+}
+
+// strictKind makes compatible changes breaking for stable APIs.
+func strictKind(kind ChangeKind, stable bool) ChangeKind {
+	if stable && kind == ChangeCompatible {
+		return ChangeBreaking
+	}
+	return kind
+}
+
+// Directives returns the directives of the API before and after the change.
+func Directives(c Change) (before, after []Directive) {
+	switch c := c.(type) {
+	case FuncChange:
+		if c.Before != nil {
+			before = c.Before.Directives
+		}
+		if c.After != nil {
+			after = c.After.Directives
+		}
+	case TypeChange:
+		if c.Before != nil {
+			before = c.Before.Directives
+		}
+		if c.After != nil {
+			after = c.After.Directives
+		}
+	case ValueChange:
+		if c.Before != nil {
+			before = c.Before.Directives
+		}
+		if c.After != nil {
+			after = c.After.Directives
+		}
+	}
+
+	return
+}
@@ -0,0 +1,82 @@
+package gompatible
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectives(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	assert.True(t, HasDirective(diff.Funcs()["Breaking5"], DirectiveExperimental))
+	assert.False(t, HasDirective(diff.Funcs()["Breaking5"], DirectiveStable))
+
+	stable := diff.Funcs()["Breaking6"]
+	assert.True(t, HasDirective(stable, DirectiveStable))
+	assert.Equal(t, ChangeBreaking, stable.Kind())
+	assert.Contains(t, Reasons(stable), "the API is marked stable")
+
+	before, after := Directives(diff.Funcs()["Breaking5"])
+	assert.Equal(t, []Directive{DirectiveExperimental}, before)
+	assert.Equal(t, []Directive{DirectiveExperimental}, after)
+
+	before, after = Directives(diff.Funcs()["Added1"])
+	assert.Nil(t, before)
+	assert.Nil(t, after)
+
+	_, ok := diff.Funcs()["Ignored1"]
+	assert.False(t, ok)
+}
+
+func TestDirectivesOfGenericReceivers(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go": {Data: []byte(`package lib
+
+type List[T any] struct{}
+
+// Len is not stable yet.
+//gompat:experimental
+func (l *List[T]) Len() int { return 0 }
+
+type Map[K comparable, V any] struct{}
+
+//gompat:ignore
+func (m Map[K, V]) Get(k K) V { var v V; return v }
+`)},
+		"v2/lib.go": {Data: []byte(`package lib
+
+type List[T any] struct{}
+
+// Len is not stable yet.
+//gompat:experimental
+func (l *List[T]) Len() string { return "" }
+
+type Map[K comparable, V any] struct{}
+
+//gompat:ignore
+func (m Map[K, V]) Get(k K) (V, bool) { var v V; return v, false }
+`)},
+	}
+
+	pkgs1, err := LoadFS(lib, "v1", "example.com/lib", false)
+	require.NoError(t, err)
+	pkgs2, err := LoadFS(lib, "v2", "example.com/lib", false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["example.com/lib"], pkgs2["example.com/lib"])
+
+	before, after := Directives(diff.Funcs()["List.Len"])
+	assert.Equal(t, []Directive{DirectiveExperimental}, before)
+	assert.Equal(t, []Directive{DirectiveExperimental}, after)
+
+	_, ok := diff.Funcs()["Map.Get"]
+	assert.False(t, ok)
+}
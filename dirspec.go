@@ -2,40 +2,65 @@ package gompatible
 
 import (
 	"fmt"
-	"io"
-	"io/ioutil"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"go/build"
 	"golang.org/x/tools/go/buildutil"
 
-	"sourcegraph.com/sourcegraph/go-vcs/vcs"
 	_ "sourcegraph.com/sourcegraph/go-vcs/vcs/hgcmd"
 
 	// _ "sourcegraph.com/sourcegraph/go-vcs/vcs/gitcmd"
 	_ "github.com/motemen/go-vcs-gitcmd-fastopen"
 )
 
+// Pseudo revisions which point to uncommitted source trees.
+const (
+	// RevisionWorktree is the working tree, including changes not staged.
+	RevisionWorktree = "WORKTREE"
+	// RevisionIndex is the staged contents of the git index.
+	RevisionIndex = "INDEX"
+)
+
 // DirSpec represents a virtual directory which may point to a source tree of a
-// specific Revision controlled under a VCS.
+// specific Revision controlled under a VCS, or one in a Zip archive.
 type DirSpec struct {
 	VCS      string
 	Revision string
 	Path     string
 
+	// Zip is the path to the zip archive the directory is in, if any.
+	// See NewZipDirSpec.
+	Zip string
+
+	// FS is the file system the directory is read from instead of the OS one,
+	// which is mounted at the repository root, or at a virtual directory for NewFSDirSpec.
+	// It is set to the source tree of the Revision if nil.
+	FS fs.FS
+
+	// ImportPath is the import path of the directory. If set, packages are
+	// named by it followed by their paths relative to Path, instead of by
+	// the ones derived from GOPATH. This is useful to compare directories
+	// at different places, eg. a vendored copy and its upstream.
+	ImportPath string
+
+	// Exclude is the list of glob patterns of directories and files to skip.
+	// Patterns containing a slash match paths relative to Path,
+	// and others match base names eg. "testdata", "*_gen.go".
+	Exclude []string
+
 	// vcs root directory
 	root string
+	// whether root has been looked up by repoRoot
+	rootLooked bool
 
 	pkgOverride string
-
-	ctx *build.Context
 }
 
 // NewDirSpec creates a virtual directory which may point to a source tree of a
-// specific revision controlled under a vcs.
+// specific revision controlled under a vcs. If vcs is empty and revision is given,
+// the VCS is detected from the path.
 // The path may be one of:
 //   - An absolute path
 //   - An import path
@@ -60,7 +85,17 @@ func NewDirSpec(path, vcs, revision string) (*DirSpec, error) {
 		Path:     path,
 	}
 
-	if _, err := dir.buildContext(); err != nil {
+	if vcs == "" && revision != "" && revision != RevisionWorktree {
+		backend, root, err := DetectVCS(path)
+		if err != nil {
+			return nil, err
+		}
+
+		dir.VCS = backend.Name()
+		dir.root = root
+	}
+
+	if _, err := dir.mount(); err != nil {
 		return nil, err
 	}
 
@@ -68,6 +103,10 @@ func NewDirSpec(path, vcs, revision string) (*DirSpec, error) {
 }
 
 func (dir *DirSpec) String() string {
+	if dir.Zip != "" {
+		return fmt.Sprintf("zip:%s", dir.Path)
+	}
+
 	if dir.VCS == "" || dir.Revision == "" {
 		return dir.Path
 	}
@@ -84,94 +123,125 @@ func (dir *DirSpec) ReadDir() ([]os.FileInfo, error) {
 	return buildutil.ReadDir(ctx, dir.Path)
 }
 
-func (dir *DirSpec) buildContext() (*build.Context, error) {
-	if dir.ctx != nil {
-		return dir.ctx, nil
+// findRoot finds the root directory of the repository which dir belongs to,
+// by the backend of dir.VCS, or by any known one if dir.VCS is empty.
+func (dir *DirSpec) findRoot() error {
+	if dir.root != "" {
+		return nil
 	}
 
-	ctx := build.Default // copy
+	if dir.VCS == "" {
+		_, root, err := DetectVCS(dir.Path)
+		if err != nil {
+			return err
+		}
+
+		dir.root = root
+		return nil
+	}
+
+	backend, err := dir.backend()
+	if err != nil {
+		return err
+	}
+
+	root, err := backend.Root(dir.Path)
+	if err != nil {
+		return err
+	}
+
+	dir.root = root
+
+	return nil
+}
+
+// repoRoot returns the root directory of the repository dir belongs to, or ""
+// if it is in none. As it may run VCS commands, it is only looked up on the first call.
+func (dir *DirSpec) repoRoot() string {
+	if dir.rootLooked == false {
+		dir.rootLooked = true
+		// The root is only used to show positions; ignore errors for non-repository directories
+		dir.findRoot()
+	}
+
+	return dir.root
+}
 
-	if dir.VCS != "" && dir.Revision != "" {
-		if dir.root == "" {
-			cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-			cmd.Dir = dir.Path
+func (dir *DirSpec) backend() (VCSBackend, error) {
+	backend := LookupVCS(dir.VCS)
+	if backend == nil {
+		return nil, fmt.Errorf("unknown VCS: %q", dir.VCS)
+	}
 
-			out, err := cmd.Output()
-			if err != nil {
-				return nil, err
-			}
+	return backend, nil
+}
 
-			dir.root = strings.TrimRight(string(out), "\n")
+// openTree opens the source tree at dir.Revision.
+func (dir *DirSpec) openTree() (fs.FS, error) {
+	if dir.Revision == RevisionIndex {
+		if dir.VCS != "git" {
+			return nil, fmt.Errorf("%s is not supported for %s", RevisionIndex, dir.VCS)
 		}
+		return newGitIndexFS(dir.root)
+	}
 
-		repo, err := vcs.Open(dir.VCS, dir.root)
-		if err != nil {
+	backend, err := dir.backend()
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := backend.Open(dir.root)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := repo.ResolveRevision(dir.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	vfs, err := repo.FileSystem(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	return vcsFS{vfs}, nil
+}
+
+// mount returns the file system dir is read from, which is the source tree of
+// dir.Revision mounted at the repository root, dir.FS, or the OS one.
+// The source tree is opened once and kept in dir.FS.
+func (dir *DirSpec) mount() (*mount, error) {
+	if dir.FS == nil && dir.VCS != "" && dir.Revision != "" && dir.Revision != RevisionWorktree {
+		if err := dir.findRoot(); err != nil {
 			return nil, err
 		}
 
-		commit, err := repo.ResolveRevision(dir.Revision)
+		fsys, err := dir.openTree()
 		if err != nil {
 			return nil, err
 		}
 
-		fs, err := repo.FileSystem(commit)
+		dir.FS = fsys
+	}
+
+	if dir.FS == nil {
+		abs, err := filepath.Abs(dir.Path)
 		if err != nil {
 			return nil, err
 		}
 
-		ctx.IsDir = func(path string) bool {
-			if buildutil.IsAbsPath(&ctx, path) {
-				if strings.HasPrefix(path, dir.root) {
-					var err error
-					path, err = filepath.Rel(dir.root, path)
-					if err != nil {
-						return false
-					}
-				} else {
-					fi, err := os.Stat(path)
-					return err == nil && fi.IsDir()
-				}
-			}
-
-			fi, err := fs.Stat(path)
-			return err == nil && fi.IsDir()
-		}
+		return &mount{fsys: os.DirFS(abs), root: abs, dir: dir.Path, sub: "."}, nil
+	}
 
-		ctx.OpenFile = func(path string) (io.ReadCloser, error) {
-			if buildutil.IsAbsPath(&ctx, path) {
-				// the path maybe outside of repository (for standard libraries)
-				if strings.HasPrefix(path, dir.root) {
-					var err error
-					path, err = filepath.Rel(dir.root, path)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					return os.Open(path)
-				}
-			}
-
-			return fs.Open(path)
-		}
+	return newMount(dir.FS, dir.root, dir.Path)
+}
 
-		ctx.ReadDir = func(path string) ([]os.FileInfo, error) {
-			if filepath.IsAbs(path) {
-				if strings.HasPrefix(path, dir.root) {
-					var err error
-					path, err = filepath.Rel(dir.root, path)
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					return ioutil.ReadDir(path)
-				}
-			}
-
-			return fs.ReadDir(path)
-		}
+func (dir *DirSpec) buildContext() (*build.Context, error) {
+	m, err := dir.mount()
+	if err != nil {
+		return nil, err
 	}
 
-	dir.ctx = &ctx
-
-	return dir.ctx, nil
+	return m.context(), nil
 }
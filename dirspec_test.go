@@ -0,0 +1,67 @@
+package gompatible
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestPseudoRevisions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// resolve symlinks eg. /tmp on macOS as git does
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	require.NoError(t, err)
+
+	git(t, tempDir, "init", "-q")
+
+	writeFile := func(name, content string) {
+		path := filepath.Join(tempDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	writeFile("sub/a.go", "package sub\n\nfunc Staged() {}\n")
+	git(t, tempDir, "add", ".")
+	writeFile("sub/a.go", "package sub\n\nfunc Unstaged() {}\n")
+
+	subdir := filepath.Join(tempDir, "sub")
+
+	dir, err := NewDirSpec(subdir, "git", RevisionIndex)
+	require.NoError(t, err)
+	pkgs, err := LoadDir(dir, false)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	for _, pkg := range pkgs {
+		assert.Contains(t, pkg.Funcs, "Staged")
+		assert.Equal(t, "sub/a.go", pkg.Position(pkg.Funcs["Staged"].Types.Pos()).Filename)
+	}
+
+	dir, err = NewDirSpec(subdir, "git", RevisionWorktree)
+	require.NoError(t, err)
+	pkgs, err = LoadDir(dir, false)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	for _, pkg := range pkgs {
+		assert.Contains(t, pkg.Funcs, "Unstaged")
+	}
+}
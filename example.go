@@ -0,0 +1,41 @@
+package gompatible
+
+import (
+	"strings"
+)
+
+// BreakingExample returns a snippet of client code, which compiles against the package
+// before the change of the API name and does not after it, eg. a call with the old arity,
+// an unkeyed literal or an assignment of an implementation of an interface.
+// It returns an empty string if the change is not breaking nor a removal, or no snippet is found.
+func BreakingExample(pc PackageChanges, name string) string {
+	if pc.Before == nil || pc.After == nil {
+		return ""
+	}
+
+	c := findChange(pc, name)
+	if c == nil {
+		return ""
+	}
+
+	switch c.Kind() {
+	case ChangeBreaking, ChangeRemoved:
+	default:
+		return ""
+	}
+
+	for _, snippet := range clientSnippets(c.TypesObject(), true) {
+		client := newClientWriter(pc.Before.TypesPkg)
+		client.writeSnippet(snippet)
+
+		if client.check(pc.Before.TypesPkg, nil) != nil {
+			continue
+		}
+
+		if client.check(pc.After.TypesPkg, pc.Before.TypesPkg) != nil {
+			return strings.TrimPrefix(client.String(), "package client\n\n")
+		}
+	}
+
+	return ""
+}
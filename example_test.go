@@ -0,0 +1,30 @@
+package gompatible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakingExample(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	assert.Equal(t, `import "testdata"
+
+func _() {
+	var v0 int
+	testdata.Breaking1(v0)
+}
+`, BreakingExample(diff, "Breaking1"))
+
+	assert.Contains(t, BreakingExample(diff, "BreakingT1"), ".XXX")
+	assert.Contains(t, BreakingExample(diff, "Removed1"), "testdata.Removed1()")
+	assert.Empty(t, BreakingExample(diff, "Compatible1"))
+	assert.Empty(t, BreakingExample(diff, "Added1"))
+}
@@ -0,0 +1,296 @@
+package gompatible
+
+import (
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sourcegraph.com/sourcegraph/go-vcs/vcs"
+)
+
+// fsRoot is the virtual directory where file systems given by NewFSDirSpec are mounted.
+var fsRoot = filepath.Join(string(filepath.Separator), "gompat-fs")
+
+// NewFSDirSpec creates a virtual directory at the slash-separated path dir in fsys.
+// Packages are named by ImportPath if set, and otherwise by their paths in fsys.
+func NewFSDirSpec(fsys fs.FS, dir string) (*DirSpec, error) {
+	if fi, err := fs.Stat(fsys, dir); err != nil {
+		return nil, err
+	} else if fi.IsDir() == false {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrInvalid}
+	}
+
+	spec := &DirSpec{
+		FS:   fsys,
+		Path: filepath.Join(fsRoot, filepath.FromSlash(dir)),
+		root: fsRoot,
+	}
+
+	if _, err := spec.mount(); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// LoadFS loads the package in the directory root of fsys, or the packages under it if recurse is true.
+// They are named by importPath followed by their paths relative to root.
+// Imports from the packages are resolved by build.Default.
+func LoadFS(fsys fs.FS, root string, importPath string, recurse bool) (map[string]*Package, error) {
+	if fi, err := fs.Stat(fsys, root); err != nil {
+		return nil, err
+	} else if fi.IsDir() == false {
+		return nil, &fs.PathError{Op: "open", Path: root, Err: fs.ErrInvalid}
+	}
+
+	if importPath == "" {
+		importPath = root
+	}
+
+	m := &mount{
+		fsys: fsys,
+		root: fsRoot,
+		dir:  filepath.Join(fsRoot, filepath.FromSlash(root)),
+		sub:  path.Clean(root),
+	}
+
+	l := &fsLoader{
+		mount:      m,
+		importPath: importPath,
+		root:       func() string { return fsRoot },
+	}
+
+	return loadFS(l, recurse)
+}
+
+// mount is a file system mounted at a directory of the OS, through which
+// build.Context reads the sources. Paths out of it, eg. of the standard
+// library, are read from the OS.
+type mount struct {
+	fsys fs.FS
+	// root is the absolute path fsys is mounted at
+	root string
+	// dir is the directory to load as passed to build.Context, and sub is its path in fsys.
+	// Relative paths are resolved against dir.
+	dir string
+	sub string
+}
+
+// newMount mounts fsys at root to load the directory dir under it.
+func newMount(fsys fs.FS, root, dir string) (*mount, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, ok := inRepository(root, dir)
+	if ok == false {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		sub, ok = inRepository(absRoot, absDir)
+		if ok == false {
+			// The root may be reported with symlinks resolved
+			if absDir, err = filepath.EvalSymlinks(absDir); err == nil {
+				sub, ok = inRepository(absRoot, absDir)
+			}
+		}
+	}
+	if ok == false {
+		return nil, fmt.Errorf("%s is not in %s", dir, root)
+	}
+
+	return &mount{fsys: fsys, root: absRoot, dir: dir, sub: sub}, nil
+}
+
+// inRepository returns the slash-separated path of name relative to root,
+// reporting false if name is not under root.
+func inRepository(root, name string) (string, bool) {
+	rel, err := filepath.Rel(root, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// path maps the path passed to build.Context to the one in m.fsys,
+// reporting false if it is out of the mount.
+func (m *mount) path(name string) (string, bool) {
+	if filepath.IsAbs(name) {
+		return inRepository(m.root, name)
+	}
+
+	rel, err := filepath.Rel(m.dir, name)
+	if err != nil {
+		return "", false
+	}
+
+	p := path.Join(m.sub, filepath.ToSlash(rel))
+	return p, fs.ValidPath(p)
+}
+
+// context returns build.Default reading the files through m.
+func (m *mount) context() *build.Context {
+	ctx := build.Default // copy
+
+	ctx.IsDir = func(name string) bool {
+		var fi os.FileInfo
+		var err error
+		if p, ok := m.path(name); ok {
+			fi, err = fs.Stat(m.fsys, p)
+		} else {
+			fi, err = os.Stat(name)
+		}
+		return err == nil && fi.IsDir()
+	}
+
+	ctx.OpenFile = func(name string) (io.ReadCloser, error) {
+		if p, ok := m.path(name); ok {
+			return m.fsys.Open(p)
+		}
+		return os.Open(name)
+	}
+
+	ctx.ReadDir = func(name string) ([]os.FileInfo, error) {
+		if p, ok := m.path(name); ok {
+			return readDirInfo(m.fsys, p)
+		}
+		return ioutil.ReadDir(name)
+	}
+
+	return &ctx
+}
+
+// readDirInfo is fs.ReadDir returning os.FileInfo, which build.Context requires.
+func readDirInfo(fsys fs.FS, name string) ([]os.FileInfo, error) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, fi)
+	}
+
+	return infos, nil
+}
+
+// vcsFS adapts vcs.FileSystem to fs.FS.
+type vcsFS struct {
+	vfs vcs.FileSystem
+}
+
+func (fsys vcsFS) Open(name string) (fs.File, error) {
+	if fs.ValidPath(name) == false {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	fi, err := fsys.vfs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return &treeFile{info: fi}, nil
+	}
+
+	r, err := fsys.vfs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &treeFile{ReadCloser: r, info: fi}, nil
+}
+
+func (fsys vcsFS) Stat(name string) (fs.FileInfo, error) {
+	return fsys.vfs.Stat(name)
+}
+
+func (fsys vcsFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	infos, err := fsys.vfs.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, len(infos))
+	for i, fi := range infos {
+		entries[i] = fs.FileInfoToDirEntry(fi)
+	}
+
+	return entries, nil
+}
+
+// treeFile is an fs.File of a virtual source tree.
+// Directories have no ReadCloser and cannot be read.
+type treeFile struct {
+	io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *treeFile) Read(p []byte) (int, error) {
+	if f.ReadCloser == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.info.Name(), Err: fs.ErrInvalid}
+	}
+	return f.ReadCloser.Read(p)
+}
+
+func (f *treeFile) Close() error {
+	if f.ReadCloser == nil {
+		return nil
+	}
+	return f.ReadCloser.Close()
+}
+
+// treeFileInfo is an fs.FileInfo of an entry in a virtual source tree, eg. the git index.
+type treeFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi treeFileInfo) Name() string       { return fi.name }
+func (fi treeFileInfo) Size() int64        { return fi.size }
+func (fi treeFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi treeFileInfo) IsDir() bool        { return fi.dir }
+func (fi treeFileInfo) Sys() interface{}   { return nil }
+
+func (fi treeFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// dirEntries groups the entries of a virtual source tree by their parent directories.
+type dirEntries map[string][]fs.DirEntry
+
+// add registers the file or directory at the slash-separated name to its parent directories.
+func (dirs dirEntries) add(name string, fi fs.FileInfo) {
+	dir := path.Dir(name)
+
+	_, seen := dirs[dir]
+	dirs[dir] = append(dirs[dir], fs.FileInfoToDirEntry(fi))
+
+	if !seen && dir != "." {
+		dirs.add(dir, treeFileInfo{name: path.Base(dir), dir: true})
+	}
+}
@@ -0,0 +1,76 @@
+package gompatible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFS(t *testing.T) {
+	fsys := os.DirFS("testdata")
+
+	pkgs1, err := LoadFS(fsys, "before", "testdata", false)
+	require.NoError(t, err)
+	pkgs2, err := LoadFS(fsys, "after", "testdata", false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+	assert.Equal(t, ChangeBreaking, diff.Funcs()["Breaking1"].Kind())
+	assert.Equal(t, "before/t.go", diff.Funcs()["Breaking1"].PosBefore().Filename)
+
+	mapFS := fstest.MapFS{
+		"src/a.go":       {Data: []byte("package a\n\nimport \"strings\"\n\nfunc A() *strings.Builder { return nil }\n")},
+		"src/sub/b.go":   {Data: []byte("package sub\n\nfunc B() {}\n")},
+		"src/sub/b_test": {Data: []byte("not a go file")},
+	}
+
+	pkgs, err := LoadFS(mapFS, "src", "example.com/a", true)
+	require.NoError(t, err)
+	require.Contains(t, pkgs, "example.com/a")
+	require.Contains(t, pkgs, "example.com/a/sub")
+	assert.Contains(t, pkgs["example.com/a"].Funcs, "A")
+
+	_, err = LoadFS(mapFS, "nonexistent", "", false)
+	assert.Error(t, err)
+}
+
+func TestMountPath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "repo")
+	m := &mount{root: root, dir: "lib", sub: "lib"}
+
+	tests := []struct {
+		name string
+		path string
+		ok   bool
+	}{
+		{name: "lib", path: "lib", ok: true},
+		{name: filepath.Join("lib", "a.go"), path: "lib/a.go", ok: true},
+		{name: filepath.Join("lib", "..", "main.go"), path: "main.go", ok: true},
+		{name: filepath.Join("lib", "..", "..", "main.go"), ok: false},
+		{name: filepath.Join(root, "lib", "a.go"), path: "lib/a.go", ok: true},
+		{name: root, path: ".", ok: true},
+		{name: filepath.Join(root+"2", "a.go"), ok: false},
+		{name: filepath.Join(string(filepath.Separator), "usr", "lib", "go", "src", "fmt"), ok: false},
+	}
+
+	for _, test := range tests {
+		p, ok := m.path(test.name)
+		assert.Equal(t, test.ok, ok, test.name)
+		if test.ok {
+			assert.Equal(t, test.path, p, test.name)
+		}
+	}
+}
+
+func TestNewMount(t *testing.T) {
+	m, err := newMount(fstest.MapFS{}, "repo", filepath.Join("repo", "lib"))
+	require.NoError(t, err)
+	assert.Equal(t, "lib", m.sub)
+
+	_, err = newMount(fstest.MapFS{}, "repo", "repo2")
+	assert.Error(t, err)
+}
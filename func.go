@@ -1,6 +1,7 @@
 package gompatible
 
 import (
+	"go/token"
 	"go/types"
 )
 
@@ -30,7 +31,29 @@ func (fc FuncChange) ShowAfter() string {
 	return f.Package.showASTNode(f.Doc.Decl)
 }
 
+func (fc FuncChange) PosBefore() token.Position {
+	f := fc.Before
+	if f == nil {
+		return token.Position{}
+	}
+	return f.Package.Position(f.Types.Pos())
+}
+
+func (fc FuncChange) PosAfter() token.Position {
+	f := fc.After
+	if f == nil {
+		return token.Position{}
+	}
+	return f.Package.Position(f.Types.Pos())
+}
+
+// Kind returns the kind of the change. Compatible changes of APIs
+// marked stable by the directive are considered breaking.
 func (fc FuncChange) Kind() ChangeKind {
+	return strictKind(fc.kind(), HasDirective(fc, DirectiveStable))
+}
+
+func (fc FuncChange) kind() ChangeKind {
 	switch {
 	case fc.Before == nil && fc.After == nil:
 		// might not happen
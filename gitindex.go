@@ -0,0 +1,259 @@
+package gompatible
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gitIndexFS is an fs.FS of the contents staged in the git index.
+// The contents are read by a "git cat-file --batch" process, which is
+// started on demand and stopped by Close.
+type gitIndexFS struct {
+	root string
+	// blobs maps slash-separated paths of files to their object names
+	blobs map[string]string
+	// sizes maps object names to their sizes
+	sizes map[string]int64
+	// dirs maps slash-separated paths of directories to their entries
+	dirs dirEntries
+
+	mu    sync.Mutex
+	batch *catFileBatch
+}
+
+func newGitIndexFS(root string) (*gitIndexFS, error) {
+	cmd := exec.Command("git", "ls-files", "--stage", "-z")
+	cmd.Dir = root
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files: %s", err)
+	}
+
+	fsys := &gitIndexFS{
+		root:  root,
+		blobs: map[string]string{},
+		dirs:  dirEntries{".": nil},
+	}
+
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// <mode> SP <object> SP <stage> TAB <file>
+		tab := strings.IndexByte(entry, '\t')
+		if tab == -1 {
+			continue
+		}
+
+		fields := strings.Fields(entry[:tab])
+		if len(fields) != 3 || fields[2] != "0" {
+			// skip unmerged entries
+			continue
+		}
+
+		name := entry[tab+1:]
+		fsys.blobs[name] = fields[1]
+	}
+
+	sizes, err := gitObjectSizes(root, fsys.blobs)
+	if err != nil {
+		return nil, err
+	}
+	fsys.sizes = sizes
+
+	for name, object := range fsys.blobs {
+		fsys.dirs.add(name, treeFileInfo{name: path.Base(name), size: sizes[object]})
+	}
+
+	for _, entries := range fsys.dirs {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	}
+
+	return fsys, nil
+}
+
+// gitObjectSizes returns the sizes of the objects, the values of blobs,
+// by one "git cat-file --batch-check" process.
+func gitObjectSizes(root string, blobs map[string]string) (map[string]int64, error) {
+	var in bytes.Buffer
+	for _, object := range blobs {
+		fmt.Fprintln(&in, object)
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch-check")
+	cmd.Dir = root
+	cmd.Stdin = &in
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch-check: %s", err)
+	}
+
+	sizes := map[string]int64{}
+	for _, line := range strings.Split(string(out), "\n") {
+		// <object> SP <type> SP <size>, or <object> SP missing
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file --batch-check: %q", line)
+		}
+		sizes[fields[0]] = size
+	}
+
+	return sizes, nil
+}
+
+func (fsys *gitIndexFS) Open(name string) (fs.File, error) {
+	fi, err := fsys.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return &treeFile{info: fi}, nil
+	}
+
+	data, err := fsys.readBlob(fsys.blobs[name])
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &treeFile{ReadCloser: ioutil.NopCloser(bytes.NewReader(data)), info: fi}, nil
+}
+
+func (fsys *gitIndexFS) Stat(name string) (fs.FileInfo, error) {
+	if fs.ValidPath(name) == false {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if _, ok := fsys.dirs[name]; ok {
+		return treeFileInfo{name: path.Base(name), dir: true}, nil
+	}
+
+	if object, ok := fsys.blobs[name]; ok {
+		return treeFileInfo{name: path.Base(name), size: fsys.sizes[object]}, nil
+	}
+
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// readBlob reads the contents of the object, starting the batch process if not running.
+// Files are read concurrently by the loader.
+func (fsys *gitIndexFS) readBlob(object string) ([]byte, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if fsys.batch == nil {
+		batch, err := startCatFileBatch(fsys.root)
+		if err != nil {
+			return nil, err
+		}
+		fsys.batch = batch
+	}
+
+	data, err := fsys.batch.read(object)
+	if err != nil {
+		// the process may be broken
+		fsys.batch.close()
+		fsys.batch = nil
+	}
+
+	return data, err
+}
+
+// Close stops the batch process, which is started again if files are read later.
+func (fsys *gitIndexFS) Close() error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	if fsys.batch == nil {
+		return nil
+	}
+
+	err := fsys.batch.close()
+	fsys.batch = nil
+	return err
+}
+
+func (fsys *gitIndexFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, ok := fsys.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entries, nil
+}
+
+// catFileBatch is a "git cat-file --batch" process, which reads objects one by one.
+type catFileBatch struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+func startCatFileBatch(root string) (*catFileBatch, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = root
+
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %s", err)
+	}
+
+	return &catFileBatch{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+func (b *catFileBatch) read(object string) ([]byte, error) {
+	if _, err := fmt.Fprintln(b.in, object); err != nil {
+		return nil, err
+	}
+
+	// <object> SP <type> SP <size> LF <contents> LF, or <object> SP missing LF
+	header, err := b.out.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("git cat-file --batch: %s", strings.TrimSpace(header))
+	}
+
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %s", strings.TrimSpace(header))
+	}
+
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(b.out, data); err != nil {
+		return nil, err
+	}
+
+	return data[:size], nil
+}
+
+func (b *catFileBatch) close() error {
+	b.in.Close()
+	return b.cmd.Wait()
+}
@@ -0,0 +1,72 @@
+package gompatible
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitIndexFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	git(t, tempDir, "init", "-q")
+
+	for name, content := range map[string]string{
+		"a.go":     "package a\n",
+		"sub/b.go": "package sub\n\nfunc B() {}\n",
+		"empty":    "",
+	} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	git(t, tempDir, "add", ".")
+
+	// Not staged
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "a.go"), []byte("package changed\n"), 0644))
+
+	fsys, err := newGitIndexFS(tempDir)
+	require.NoError(t, err)
+	defer fsys.Close()
+
+	entries, err := fs.ReadDir(fsys, ".")
+	require.NoError(t, err)
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"a.go", "empty", "sub"}, names)
+
+	data, err := fs.ReadFile(fsys, "empty")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+
+	fi, err := fs.Stat(fsys, "sub/b.go")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("package sub\n\nfunc B() {}\n")), fi.Size())
+
+	data, err = fs.ReadFile(fsys, "a.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(data))
+
+	_, err = fs.Stat(fsys, "missing.go")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	// Files can be read after closed
+	require.NoError(t, fsys.Close())
+	data, err = fs.ReadFile(fsys, "sub/b.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package sub\n\nfunc B() {}\n", string(data))
+}
@@ -0,0 +1,340 @@
+package gompatible
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// Dependent is a package which may depend on the packages whose changes are examined,
+// parsed but not type-checked.
+type Dependent struct {
+	ImportPath string
+	Fset       *token.FileSet
+	Files      []*ast.File
+}
+
+// imports reports whether any of the files of the package imports the package at path.
+func (d *Dependent) imports(path string) bool {
+	for _, f := range d.Files {
+		for _, imp := range f.Imports {
+			if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LoadDependents parses the package in the local directory dir, or the packages under it if recurse is true,
+// to the file set. Directories named "testdata" or "vendor", or starting with "." or "_" are skipped,
+// and test files are not loaded. Packages are named by their import paths derived from GOPATH,
+// or by their directories.
+func LoadDependents(fset *token.FileSet, dir string, recurse bool) ([]*Dependent, error) {
+	deps := []*Dependent{}
+
+	p, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		if _, ok := err.(*build.NoGoError); !ok {
+			return nil, fmt.Errorf("while loading %s: %s", dir, err)
+		}
+	} else {
+		dep := &Dependent{ImportPath: p.ImportPath, Fset: fset}
+		if dep.ImportPath == "." {
+			dep.ImportPath = p.Dir
+		}
+
+		for _, file := range p.GoFiles {
+			f, err := parser.ParseFile(fset, filepath.Join(p.Dir, file), nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			dep.Files = append(dep.Files, f)
+		}
+
+		if len(dep.Files) > 0 {
+			deps = append(deps, dep)
+		}
+	}
+
+	if recurse == false {
+		return deps, nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() == false {
+			continue
+		}
+
+		if name := e.Name(); name[0] == '.' || name[0] == '_' || name == "testdata" || name == "vendor" {
+			continue
+		}
+
+		sub, err := LoadDependents(fset, filepath.Join(dir, e.Name()), recurse)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, sub...)
+	}
+
+	return deps, nil
+}
+
+// UsageKind represents how a dependent package uses an API.
+type UsageKind int
+
+const (
+	UsageReference UsageKind = iota
+	UsageCall
+	UsageLiteral
+	UsageImplementation
+)
+
+func (uk UsageKind) String() string {
+	switch uk {
+	case UsageReference:
+		return "Reference"
+	case UsageCall:
+		return "Call"
+	case UsageLiteral:
+		return "Literal"
+	case UsageImplementation:
+		return "Implementation"
+	}
+
+	return ""
+}
+
+// Usage is a use of a changed API in a dependent package.
+type Usage struct {
+	// Name is the name of the API, eg. "Func" or "Type.Method".
+	// Uses of fields are attributed to the types they belong to
+	Name   string
+	Change Change
+	Kind   UsageKind
+	// Package is the import path of the dependent package
+	Package string
+	// Pos is the position of the reference, or of the type declaration for implementations
+	Pos token.Position
+	// Err is the error type-checking the use against the package after the change,
+	// or nil if it is not broken by the change
+	Err error
+}
+
+// Broken reports whether the use does not compile after the change.
+func (u *Usage) Broken() bool {
+	return u.Err != nil
+}
+
+// FindUsages finds the uses of the changed APIs of the package in the dependents importing it
+// by importPath: references, calls of functions, composite literals of types, and implementations
+// of interfaces by types declared in the dependents. Each dependent is type-checked against
+// the packages before and after the change to find the uses broken by the change.
+// Other imports of the dependents are resolved by build.Default.
+func FindUsages(pc PackageChanges, importPath string, deps []*Dependent) []*Usage {
+	if pc.Before == nil || pc.After == nil {
+		return nil
+	}
+
+	names := changedObjectNames(pc)
+
+	usages := []*Usage{}
+	importers := map[*token.FileSet]types.Importer{}
+	for _, dep := range deps {
+		if dep.imports(importPath) == false {
+			continue
+		}
+
+		fallback, ok := importers[dep.Fset]
+		if !ok {
+			fallback = importer.ForCompiler(dep.Fset, "source", nil)
+			importers[dep.Fset] = fallback
+		}
+
+		info := &types.Info{
+			Uses:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+		}
+		pkgBefore, errsBefore := checkClientFiles(dep.Fset, dep.Files, dep.ImportPath, importPath, pc.Before.TypesPkg, nil, fallback, info)
+		pkgAfter, errsAfter := checkClientFiles(dep.Fset, dep.Files, dep.ImportPath, importPath, pc.After.TypesPkg, pc.Before.TypesPkg, fallback, nil)
+
+		refs := map[token.Pos]*Usage{}
+		add := func(name string, kind UsageKind, pos token.Pos) *Usage {
+			u := &Usage{
+				Name:    name,
+				Change:  findChange(pc, name),
+				Kind:    kind,
+				Package: dep.ImportPath,
+				Pos:     dep.Fset.Position(pos),
+			}
+			usages = append(usages, u)
+			return u
+		}
+
+		for _, file := range dep.Files {
+			collectReferences(file, info, names, func(name string, kind UsageKind, pos token.Pos) {
+				refs[pos] = add(name, kind, pos)
+			})
+		}
+
+		for _, err := range newErrors(errsBefore, errsAfter) {
+			if _, ref := attributeError(dep.Files, err.Pos, info, names); ref.IsValid() {
+				if u := refs[ref]; u != nil && u.Err == nil {
+					u.Err = err
+				}
+			}
+		}
+
+		if pkgBefore == nil {
+			continue
+		}
+
+		for _, impl := range findImplementations(pc, pkgBefore, pkgAfter) {
+			u := add(impl.name, UsageImplementation, impl.typ.Pos())
+			u.Err = impl.err
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		pi, pj := usages[i].Pos, usages[j].Pos
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+
+	return usages
+}
+
+// collectReferences calls found for each reference to the changed APIs in the file,
+// with the kind of the use and the position of the identifier.
+func collectReferences(file *ast.File, info *types.Info, names map[types.Object]string, found func(name string, kind UsageKind, pos token.Pos)) {
+	stack := []ast.Node{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if sel, ok := info.Selections[n]; ok && sel.Kind() == types.FieldVal {
+				if named, ok := derefNamed(sel.Recv()); ok {
+					if name := names[named.Obj()]; name != "" {
+						found(name, UsageReference, n.Sel.Pos())
+					}
+				}
+			}
+
+		case *ast.Ident:
+			obj := info.Uses[n]
+			if name := names[obj]; name != "" {
+				found(name, usageKind(stack, obj), n.Pos())
+			}
+		}
+
+		return true
+	})
+}
+
+// usageKind classifies the use of obj by the identifier at the top of the stack of nodes.
+func usageKind(stack []ast.Node, obj types.Object) UsageKind {
+	var expr ast.Node = stack[len(stack)-1]
+	i := len(stack) - 2
+	if i >= 0 {
+		if sel, ok := stack[i].(*ast.SelectorExpr); ok && sel.Sel == expr {
+			expr = sel
+			i--
+		}
+	}
+	if i < 0 {
+		return UsageReference
+	}
+
+	switch parent := stack[i].(type) {
+	case *ast.CallExpr:
+		if _, ok := obj.(*types.Func); ok && parent.Fun == expr {
+			return UsageCall
+		}
+	case *ast.CompositeLit:
+		if _, ok := obj.(*types.TypeName); ok && parent.Type == expr {
+			return UsageLiteral
+		}
+	}
+
+	return UsageReference
+}
+
+type implementation struct {
+	name string
+	typ  *types.TypeName
+	err  error
+}
+
+// findImplementations finds the types declared in the dependent which implement the changed
+// interfaces of the package before the change, with the errors if they do not implement
+// the interfaces after the change.
+func findImplementations(pc PackageChanges, depBefore, depAfter *types.Package) []implementation {
+	impls := []implementation{}
+
+	changes := pc.Changes[ObjectCategoryType]
+	for _, name := range util.SortedStringSet(util.MapKeys(changes)) {
+		tc, ok := changes[name].(TypeChange)
+		if !ok || tc.Before == nil {
+			continue
+		}
+
+		iface, ok := tc.Before.Types.Type().Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 {
+			continue
+		}
+
+		for _, n := range depBefore.Scope().Names() {
+			obj, ok := depBefore.Scope().Lookup(n).(*types.TypeName)
+			if !ok || types.IsInterface(obj.Type()) || !implements(obj.Type(), iface) {
+				continue
+			}
+
+			impl := implementation{name: name, typ: obj}
+
+			if tc.After != nil && depAfter != nil {
+				ifaceAfter, _ := tc.After.Types.Type().Underlying().(*types.Interface)
+				objAfter, _ := depAfter.Scope().Lookup(n).(*types.TypeName)
+				if ifaceAfter != nil && objAfter != nil && !implements(objAfter.Type(), ifaceAfter) {
+					m, wrongType := types.MissingMethod(types.NewPointer(objAfter.Type()), ifaceAfter, true)
+					if wrongType {
+						impl.err = fmt.Errorf("%s does not implement %s (wrong type for method %s)", n, name, m.Name())
+					} else {
+						impl.err = fmt.Errorf("%s does not implement %s (missing method %s)", n, name, m.Name())
+					}
+				}
+			}
+
+			impls = append(impls, impl)
+		}
+	}
+
+	return impls
+}
+
+// implements reports whether the type or the pointer to it implements the interface.
+func implements(t types.Type, iface *types.Interface) bool {
+	return types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface)
+}
@@ -0,0 +1,90 @@
+package gompatible
+
+import (
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUsages(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go": {Data: []byte(`package lib
+
+func F(n int) int { return n }
+
+func G() {}
+
+type T struct{ X int }
+
+type I interface{ M() }
+`)},
+		"v2/lib.go": {Data: []byte(`package lib
+
+func F(n int, s string) int { return n }
+
+func G() {}
+
+type T struct{ Y int }
+
+type I interface{ M(n int) }
+`)},
+	}
+
+	pkgs1, err := LoadFS(lib, "v1", "example.com/lib", false)
+	require.NoError(t, err)
+	pkgs2, err := LoadFS(lib, "v2", "example.com/lib", false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["example.com/lib"], pkgs2["example.com/lib"])
+
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "svc"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "svc", "svc.go"), []byte(`package svc
+
+import "example.com/lib"
+
+type impl struct{}
+
+func (impl) M() {}
+
+func Run() int {
+	lib.G()
+	t := lib.T{X: 1}
+	return lib.F(t.X)
+}
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "other.go"), []byte("package other\n"), 0644))
+
+	deps, err := LoadDependents(token.NewFileSet(), tempDir, true)
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+
+	usages := FindUsages(diff, "example.com/lib", deps)
+
+	type usage struct {
+		Name   string
+		Kind   UsageKind
+		Line   int
+		Broken bool
+	}
+	got := []usage{}
+	for _, u := range usages {
+		got = append(got, usage{u.Name, u.Kind, u.Pos.Line, u.Broken()})
+	}
+
+	assert.Equal(t, []usage{
+		{"I", UsageImplementation, 5, true},
+		{"T", UsageLiteral, 11, true},
+		{"F", UsageCall, 12, true},
+		{"T", UsageReference, 12, true},
+	}, got)
+}
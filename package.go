@@ -3,6 +3,10 @@ package gompatible
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"go/ast"
 	"go/build"
@@ -28,73 +32,138 @@ type Package struct {
 	Values map[string]*Value
 
 	Fset *token.FileSet
+
+	// root returns the root directory of the repository the package is loaded from,
+	// or "" if unknown. It is called by Position on demand, as it may run VCS commands
+	root func() string
+
+	// The file names of the external test package and the context to read them,
+	// which are parsed on demand by testFiles. See CheckTests
+	testCtx       *build.Context
+	testFilenames []string
+	parsedTests   []*ast.File
+	testsParsed   bool
 }
 
 // Func is a syntactically parsed, type-checked and (maybe) documented function.
 type Func struct {
-	Package *Package
-	Types   *types.Func
-	Doc     *doc.Func
+	Package    *Package
+	Types      *types.Func
+	Doc        *doc.Func
+	Directives []Directive
 }
 
 // Type is a syntactically parsed, type-checked and (maybe) documented type declaration.
 type Type struct {
-	Package *Package
-	Types   *types.TypeName
-	Doc     *doc.Type
-	Funcs   map[string]*Func
-	Methods map[string]*Func
+	Package    *Package
+	Types      *types.TypeName
+	Doc        *doc.Type
+	Funcs      map[string]*Func
+	Methods    map[string]*Func
+	Directives []Directive
 }
 
 // Value is a syntactically parsed, type-checked and (maybe) documented toplevel value (var or const).
 type Value struct {
-	Name    string
-	Package *Package
-	Doc     *doc.Value
-	Types   types.Object // *types.Var (IsConst == false) or *types.Const (IsConst == true)
-	IsConst bool
+	Name       string
+	Package    *Package
+	Doc        *doc.Value
+	Types      types.Object // *types.Var (IsConst == false) or *types.Const (IsConst == true)
+	IsConst    bool
+	Directives []Directive
 }
 
-// XXX should the return value be a map from dir to files? (currently assumed importPath to files)
-func listDirFiles(dir *DirSpec, recurse bool) (map[string][]string, error) {
-	ctx, err := dir.buildContext()
+// fsLoader lists and loads the packages in a directory of a mount.
+type fsLoader struct {
+	*mount
+
+	// importPath names the packages followed by their paths relative to the directory, if set
+	importPath string
+	// pkgOverride names the package of the directory, if set
+	pkgOverride string
+	exclude     []string
+
+	// root returns the directory positions are shown relative to, if any
+	root func() string
+}
+
+// loadFS loads the package in the directory of l, or the packages under it if recurse is true.
+// LoadFS and LoadDir are built on this.
+func loadFS(l *fsLoader, recurse bool) (map[string]*Package, error) {
+	ctx := l.context()
+
+	// eg. stop the processes reading the git index, which are started again for the tests
+	if c, ok := l.fsys.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	files, testFiles, err := l.listFiles(ctx, recurse)
+	if err != nil {
+		return nil, err
+	}
+
+	packages, err := LoadPackages(ctx, files)
 	if err != nil {
 		return nil, err
 	}
 
-	packages := map[string][]string{}
+	for path, pkg := range packages {
+		pkg.testCtx, pkg.testFilenames = ctx, testFiles[path]
+		pkg.root = l.root
+	}
 
+	return packages, nil
+}
+
+// XXX should the return value be a map from dir to files? (currently assumed importPath to files)
+// The files of the external test packages are returned separately as testFiles, keyed by the same import paths.
+func (l *fsLoader) listFiles(ctx *build.Context, recurse bool) (files map[string][]string, testFiles map[string][]string, err error) {
+	files, testFiles = map[string][]string{}, map[string][]string{}
+	err = l.listFilesRel(ctx, l.dir, "", recurse, files, testFiles)
+	return
+}
+
+// listFilesRel does listFiles for the subdirectory dir at rel, the slash-separated path relative
+// to the directory where listing has started, adding the files to packages and testPackages.
+func (l *fsLoader) listFilesRel(ctx *build.Context, dir, rel string, recurse bool, packages, testPackages map[string][]string) error {
 	var mode build.ImportMode
-	p, err := ctx.ImportDir(dir.Path, mode)
+	p, err := ctx.ImportDir(dir, mode)
 	if err != nil {
 		if _, ok := err.(*build.NoGoError); ok {
 			// nop
 		} else {
-			return nil, fmt.Errorf("while loading %s: %s", dir, err)
+			return fmt.Errorf("while loading %s: %s", dir, err)
 		}
 	} else {
 		importPath := p.ImportPath
 		if importPath == "." {
 			importPath = p.Dir
 		}
-		if dir.pkgOverride != "" {
-			importPath = dir.pkgOverride
+		if l.importPath != "" {
+			importPath = path.Join(l.importPath, rel)
+		}
+		if l.pkgOverride != "" {
+			importPath = l.pkgOverride
 		}
 
 		// XXX something's wrong if packages[importPath] exists already
-		packages[importPath] = make([]string, len(p.GoFiles))
-		for i, file := range p.GoFiles {
-			packages[importPath][i] = buildutil.JoinPath(ctx, dir.Path, file)
+		files := l.joinFiles(ctx, dir, rel, p.GoFiles)
+		if len(files) > 0 {
+			packages[importPath] = files
+
+			if testFiles := l.joinFiles(ctx, dir, rel, p.XTestGoFiles); len(testFiles) > 0 {
+				testPackages[importPath] = testFiles
+			}
 		}
 	}
 
 	if recurse == false {
-		return packages, nil
+		return nil
 	}
 
-	entries, err := dir.ReadDir()
+	entries, err := buildutil.ReadDir(ctx, dir)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for _, e := range entries {
@@ -106,34 +175,66 @@ func listDirFiles(dir *DirSpec, recurse bool) (map[string][]string, error) {
 			continue
 		}
 
-		// copy
-		subdir := *dir
-		subdir.Path = buildutil.JoinPath(ctx, dir.Path, e.Name())
+		subrel := path.Join(rel, e.Name())
+		if l.excluded(subrel) {
+			continue
+		}
 
-		pkgs, err := listDirFiles(&subdir, recurse)
-		if err != nil {
-			return nil, err
+		subdir := buildutil.JoinPath(ctx, dir, e.Name())
+		if err := l.listFilesRel(ctx, subdir, subrel, recurse, packages, testPackages); err != nil {
+			return err
 		}
-		for path, files := range pkgs {
-			packages[path] = files
+	}
+
+	return nil
+}
+
+// joinFiles returns the paths of the files in the directory dir at rel, except excluded ones.
+func (l *fsLoader) joinFiles(ctx *build.Context, dir, rel string, names []string) []string {
+	files := make([]string, 0, len(names))
+	for _, file := range names {
+		if l.excluded(path.Join(rel, file)) {
+			continue
 		}
+		files = append(files, buildutil.JoinPath(ctx, dir, file))
 	}
+	return files
+}
 
-	return packages, nil
+// excluded reports whether the slash-separated path relative to the directory
+// matches any of l.exclude.
+func (l *fsLoader) excluded(rel string) bool {
+	for _, pat := range l.exclude {
+		name := rel
+		if strings.Contains(pat, "/") == false {
+			name = path.Base(rel)
+		}
+
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+
+	return false
 }
 
+// LoadDir loads the package in dir, or the packages under it if recurse is true,
+// reading dir as a file system by loadFS.
 func LoadDir(dir *DirSpec, recurse bool) (map[string]*Package, error) {
-	ctx, err := dir.buildContext()
+	m, err := dir.mount()
 	if err != nil {
 		return nil, err
 	}
 
-	files, err := listDirFiles(dir, recurse)
-	if err != nil {
-		return nil, err
+	l := &fsLoader{
+		mount:       m,
+		importPath:  dir.ImportPath,
+		pkgOverride: dir.pkgOverride,
+		exclude:     dir.Exclude,
+		root:        dir.repoRoot,
 	}
 
-	return LoadPackages(ctx, files)
+	return loadFS(l, recurse)
 }
 
 func LoadPackages(ctx *build.Context, filepaths map[string][]string) (map[string]*Package, error) {
@@ -169,10 +270,15 @@ func packageFromInfo(prog *loader.Program, pkgInfo *loader.PackageInfo) *Package
 	// Ignore (perhaps) "unresolved identifier" errors
 	astPkg, _ := ast.NewPackage(prog.Fset, files, nil, nil)
 
+	directives := collectDirectives(files)
+
 	var mode doc.Mode
 	docPkg := doc.New(astPkg, pkgInfo.String(), mode)
 
-	return NewPackage(prog.Fset, docPkg, pkgInfo.Pkg)
+	pkg := NewPackage(prog.Fset, docPkg, pkgInfo.Pkg)
+	pkg.applyDirectives(directives)
+
+	return pkg
 }
 
 // NewPackage builds a Package from one from doc and types package.
@@ -293,6 +399,51 @@ func (p *Package) buildValues() map[string]*Value {
 	return p.Values
 }
 
+// testFiles parses the files of the external test package on the first call, and returns them.
+// Files which cannot be parsed are skipped, as tests are not part of the API.
+func (p *Package) testFiles() []*ast.File {
+	if p.testsParsed {
+		return p.parsedTests
+	}
+	p.testsParsed = true
+
+	for _, filename := range p.testFilenames {
+		f, err := buildutil.ParseFile(p.Fset, p.testCtx, nil, "", filename, parser.ParseComments)
+		if err != nil {
+			Debugf("skipping %s: %s", filename, err)
+			continue
+		}
+		p.parsedTests = append(p.parsedTests, f)
+	}
+
+	return p.parsedTests
+}
+
+// Position returns the position of pos, with the file name relative to
+// the repository root if it is known.
+func (p *Package) Position(pos token.Pos) token.Position {
+	position := p.Fset.Position(pos)
+	if position.IsValid() == false || p.root == nil {
+		return position
+	}
+
+	root := p.root()
+	if root == "" {
+		return position
+	}
+
+	filename, err := filepath.Abs(position.Filename)
+	if err != nil {
+		return position
+	}
+
+	if rel, ok := inRepository(root, filename); ok {
+		position.Filename = filepath.FromSlash(rel)
+	}
+
+	return position
+}
+
 // showASTNode takes an AST node to return its string presentation.
 func (p Package) showASTNode(node interface{}) string {
 	var buf bytes.Buffer
@@ -0,0 +1,29 @@
+package gompatible
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDirExclude(t *testing.T) {
+	pkgs, err := LoadDir(&DirSpec{Path: "testdata"}, true)
+	require.NoError(t, err)
+	assert.Len(t, pkgs, 2)
+
+	pkgs, err = LoadDir(&DirSpec{Path: "testdata", Exclude: []string{"after"}}, true)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	for path := range pkgs {
+		assert.Equal(t, "before", filepath.Base(path))
+	}
+
+	pkgs, err = LoadDir(&DirSpec{Path: "testdata", Exclude: []string{"before/*.go"}}, true)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	for path := range pkgs {
+		assert.Equal(t, "after", filepath.Base(path))
+	}
+}
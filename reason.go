@@ -0,0 +1,206 @@
+package gompatible
+
+import (
+	"fmt"
+
+	"go/types"
+)
+
+// Reasons returns human-readable descriptions of why the change is
+// classified as it is, eg. which parameter or which field has changed.
+// It returns nil for unchanged, added or removed APIs.
+func Reasons(c Change) []string {
+	switch c.Kind() {
+	case ChangeUnchanged, ChangeAdded, ChangeRemoved:
+		return nil
+	}
+
+	var (
+		reasons []string
+		kind    ChangeKind
+	)
+	switch c := c.(type) {
+	case FuncChange:
+		reasons, kind = c.reasons(), c.kind()
+	case TypeChange:
+		reasons, kind = c.reasons(), c.kind()
+	case ValueChange:
+		reasons, kind = c.reasons(), c.kind()
+	default:
+		return nil
+	}
+
+	if kind != c.Kind() {
+		reasons = append(reasons, "the API is marked stable")
+	}
+
+	return reasons
+}
+
+func (fc FuncChange) reasons() []string {
+	qf := types.RelativeTo(fc.After.Package.TypesPkg)
+
+	return signatureReasons(fc.Before.Types.Type().(*types.Signature), fc.After.Types.Type().(*types.Signature), qf)
+}
+
+// signatureReasons describes differences between two function signatures.
+func signatureReasons(sig1, sig2 *types.Signature, qf types.Qualifier) []string {
+	reasons := tupleReasons("parameter", sig1.Params(), sig2.Params(), qf)
+	if sig1.Variadic() != sig2.Variadic() {
+		if sig2.Variadic() {
+			reasons = append(reasons, "became variadic")
+		} else {
+			reasons = append(reasons, "no longer variadic")
+		}
+	}
+
+	return append(reasons, tupleReasons("result", sig1.Results(), sig2.Results(), qf)...)
+}
+
+func (tc TypeChange) reasons() []string {
+	qf := types.RelativeTo(tc.After.Package.TypesPkg)
+
+	t1 := tc.Before.Types.Type().Underlying()
+	t2 := tc.After.Types.Type().Underlying()
+
+	var reasons []string
+	switch t1 := t1.(type) {
+	case *types.Struct:
+		if t2, ok := t2.(*types.Struct); ok {
+			reasons = structReasons(t1, t2, qf)
+		}
+	case *types.Interface:
+		if t2, ok := t2.(*types.Interface); ok {
+			reasons = methodSetReasons(types.NewMethodSet(t1), types.NewMethodSet(t2), qf)
+		}
+	}
+	if len(reasons) > 0 {
+		return reasons
+	}
+
+	s1, s2 := types.TypeString(t1, qf), types.TypeString(t2, qf)
+	if s1 == s2 {
+		// The types differ only in the packages they refer to
+		s1, s2 = types.TypeString(t1, nil), types.TypeString(t2, nil)
+	}
+
+	return []string{
+		fmt.Sprintf("underlying type changed: %s -> %s", s1, s2),
+	}
+}
+
+func (vc ValueChange) reasons() []string {
+	qf := types.RelativeTo(vc.After.Package.TypesPkg)
+
+	var reasons []string
+
+	if vc.Before.IsConst && !vc.After.IsConst {
+		reasons = append(reasons, "const changed to var")
+	} else if !vc.Before.IsConst && vc.After.IsConst {
+		reasons = append(reasons, "var changed to const")
+	}
+
+	t1, t2 := vc.Before.Types.Type(), vc.After.Types.Type()
+	if types.TypeString(t1, qf) != types.TypeString(t2, qf) {
+		reasons = append(reasons, fmt.Sprintf("type changed: %s -> %s", types.TypeString(t1, qf), types.TypeString(t2, qf)))
+	}
+
+	return reasons
+}
+
+// tupleReasons describes differences between two parameter or result lists.
+func tupleReasons(what string, t1, t2 *types.Tuple, qf types.Qualifier) []string {
+	var reasons []string
+
+	for i := 0; i < t1.Len() || i < t2.Len(); i++ {
+		switch {
+		case i >= t1.Len():
+			reasons = append(reasons, fmt.Sprintf("%s #%d added: %s", what, i+1, types.TypeString(t2.At(i).Type(), qf)))
+
+		case i >= t2.Len():
+			reasons = append(reasons, fmt.Sprintf("%s #%d removed: %s", what, i+1, types.TypeString(t1.At(i).Type(), qf)))
+
+		default:
+			s1 := types.TypeString(t1.At(i).Type(), qf)
+			s2 := types.TypeString(t2.At(i).Type(), qf)
+			if s1 != s2 {
+				reasons = append(reasons, fmt.Sprintf("%s #%d type changed: %s -> %s", what, i+1, s1, s2))
+			}
+		}
+	}
+
+	return reasons
+}
+
+// structReasons describes differences between exported fields of two structs.
+func structReasons(s1, s2 *types.Struct, qf types.Qualifier) []string {
+	var reasons []string
+
+	fields2 := map[string]*types.Var{}
+	for i := 0; i < s2.NumFields(); i++ {
+		if f := s2.Field(i); f.Exported() {
+			fields2[f.Name()] = f
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < s1.NumFields(); i++ {
+		f1 := s1.Field(i)
+		if !f1.Exported() {
+			continue
+		}
+
+		seen[f1.Name()] = true
+
+		f2, ok := fields2[f1.Name()]
+		if !ok {
+			reasons = append(reasons, fmt.Sprintf("field %s removed", f1.Name()))
+			continue
+		}
+
+		ts1 := types.TypeString(f1.Type(), qf)
+		ts2 := types.TypeString(f2.Type(), qf)
+		if ts1 != ts2 {
+			reasons = append(reasons, fmt.Sprintf("field %s type changed: %s -> %s", f1.Name(), ts1, ts2))
+		}
+	}
+
+	for i := 0; i < s2.NumFields(); i++ {
+		if f := s2.Field(i); f.Exported() && !seen[f.Name()] {
+			reasons = append(reasons, fmt.Sprintf("field %s added", f.Name()))
+		}
+	}
+
+	return reasons
+}
+
+// methodSetReasons describes differences between exported methods of two method sets.
+func methodSetReasons(ms1, ms2 *types.MethodSet, qf types.Qualifier) []string {
+	var reasons []string
+
+	for i := 0; i < ms1.Len(); i++ {
+		m1 := ms1.At(i).Obj()
+		if !m1.Exported() {
+			continue
+		}
+
+		sel := ms2.Lookup(m1.Pkg(), m1.Name())
+		if sel == nil {
+			reasons = append(reasons, fmt.Sprintf("method %s removed", m1.Name()))
+			continue
+		}
+
+		for _, r := range signatureReasons(m1.Type().(*types.Signature), sel.Obj().Type().(*types.Signature), qf) {
+			reasons = append(reasons, fmt.Sprintf("method %s: %s", m1.Name(), r))
+		}
+	}
+
+	for i := 0; i < ms2.Len(); i++ {
+		m2 := ms2.At(i).Obj()
+		if m2.Exported() && ms1.Lookup(m2.Pkg(), m2.Name()) == nil {
+			reasons = append(reasons, fmt.Sprintf("method %s added", m2.Name()))
+		}
+	}
+
+	return reasons
+}
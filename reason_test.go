@@ -0,0 +1,72 @@
+package gompatible
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReasons(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	assert.Equal(t, []string{"parameter #2 added: bool"}, Reasons(diff.Funcs()["Breaking1"]))
+	assert.Equal(t, []string{"parameter #2 added: []string", "became variadic"}, Reasons(diff.Funcs()["Compatible1"]))
+	assert.Equal(t, []string{"result #1 type changed: string -> []byte"}, Reasons(diff.Funcs()["Breaking4"]))
+	assert.Equal(t, []string{"field XXX removed", "field YYY added"}, Reasons(diff.Types()["BreakingT1"]))
+	assert.Equal(t, []string{"var changed to const"}, Reasons(diff.Values()["BreakingV2"]))
+	assert.Equal(t, []string{"type changed: int -> AuxInt"}, Reasons(diff.Values()["BreakingV3"]))
+	assert.Nil(t, Reasons(diff.Funcs()["Removed1"]))
+}
+
+func TestTypeReasons(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go": {Data: []byte(`package lib
+
+type I interface {
+	M()
+	N()
+}
+
+type E interface{ Read(p []byte) (int, error) }
+
+type Inner struct{ A int }
+`)},
+		"v2/lib.go": {Data: []byte(`package lib
+
+import "io"
+
+type I interface {
+	M(n int)
+	O()
+}
+
+type E interface{ io.Reader }
+
+type Inner struct{ B int }
+`)},
+	}
+
+	pkgs1, err := LoadFS(lib, "v1", "example.com/lib", false)
+	require.NoError(t, err)
+	pkgs2, err := LoadFS(lib, "v2", "example.com/lib", false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["example.com/lib"], pkgs2["example.com/lib"])
+
+	assert.Equal(t, []string{
+		"method M: parameter #1 added: int",
+		"method N removed",
+		"method O added",
+	}, Reasons(diff.Types()["I"]))
+	assert.Equal(t, []string{"field A removed", "field B added"}, Reasons(diff.Types()["Inner"]))
+	assert.Equal(t, []string{
+		"underlying type changed: interface{Read(p []byte) (int, error)} -> interface{io.Reader}",
+	}, Reasons(diff.Types()["E"]))
+}
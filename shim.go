@@ -0,0 +1,381 @@
+package gompatible
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// shimHeader is the comment put at the top of shims.
+const shimHeader = "// Compatibility shims restoring the removed APIs, generated by gompat shim.\n\n"
+
+// Shim is a source file of a package, which restores the APIs removed by the change
+// on top of the package after the change.
+type Shim struct {
+	// Source is the source of the file, or nil if no APIs are restored
+	Source []byte
+	// Restored maps the names of the APIs restored to the names of the ones they forward to
+	Restored map[string]string
+	// Skipped maps the names of the removed funcs and types which could not be restored to the reasons
+	Skipped map[string]string
+}
+
+// A shimDecl writes a declaration of a shim.
+type shimDecl func(w *clientWriter)
+
+// GenerateShim generates a shim restoring the removed funcs and types of the package as deprecated
+// forwarding wrappers and type aliases of the added ones, which they are supposed to be renamed to.
+// A removed type is restored as an alias of the added type of the identical underlying type and the methods
+// of the identical signatures, and a removed
+// func as a wrapper of the added func of the identical signature. Added funcs which only accept
+// its arguments and return its results are not picked but suggested in Skipped. Methods are not restored. The shim is type-checked against
+// the package after the change, and an error is returned if it does not compile.
+func GenerateShim(pc PackageChanges) (*Shim, error) {
+	if pc.Before == nil || pc.After == nil {
+		return nil, fmt.Errorf("no shim for added or removed packages")
+	}
+
+	shim := &Shim{
+		Restored: map[string]string{},
+		Skipped:  map[string]string{},
+	}
+
+	var decls []shimDecl
+
+	typeChanges := pc.Changes[ObjectCategoryType]
+	for _, name := range util.SortedStringSet(util.MapKeys(typeChanges)) {
+		tc, ok := typeChanges[name].(TypeChange)
+		if !ok || tc.Before == nil || tc.After != nil {
+			continue
+		}
+
+		candidates, lacking := []string{}, []string{}
+		for _, cand := range util.SortedStringSet(util.MapKeys(typeChanges)) {
+			ac, ok := typeChanges[cand].(TypeChange)
+			if !ok || ac.Before != nil || ac.After == nil {
+				continue
+			}
+
+			if pathTypeString(tc.Before.Types.Type().Underlying()) != pathTypeString(ac.After.Types.Type().Underlying()) {
+				continue
+			}
+
+			// The alias must keep the methods of the removed type
+			if lost := lostMethods(pc, append(decls, aliasShim(name, cand)), name, tc.Before.Types.Type()); len(lost) > 0 {
+				lacking = append(lacking, fmt.Sprintf("%s (%s)", cand, strings.Join(lost, ", ")))
+				continue
+			}
+
+			candidates = append(candidates, cand)
+		}
+
+		if to, reason := pickShimTarget(candidates, "type of the identical underlying type"); reason != "" {
+			if len(candidates) == 0 && len(lacking) > 0 {
+				reason += "; candidates lacking methods: " + strings.Join(lacking, ", ")
+			}
+			shim.Skipped[name] = reason
+		} else {
+			shim.Restored[name] = to
+			decls = append(decls, aliasShim(name, to))
+		}
+	}
+
+	funcChanges := pc.Changes[ObjectCategoryFunc]
+	for _, name := range util.SortedStringSet(util.MapKeys(funcChanges)) {
+		fc, ok := funcChanges[name].(FuncChange)
+		if !ok || fc.Before == nil || fc.After != nil {
+			continue
+		}
+
+		if strings.Contains(name, ".") {
+			shim.Skipped[name] = "methods are not supported"
+			continue
+		}
+
+		sig := fc.Before.Types.Type().(*types.Signature)
+
+		if missing := unrestoredTypes(sig, pc.Before.TypesPkg.Path(), typeChanges, shim.Restored); len(missing) > 0 {
+			shim.Skipped[name] = "uses the removed types not restored: " + strings.Join(missing, ", ")
+			continue
+		}
+
+		identical, compatible := []string{}, []string{}
+		for _, cand := range util.SortedStringSet(util.MapKeys(funcChanges)) {
+			ac, ok := funcChanges[cand].(FuncChange)
+			if !ok || ac.Before != nil || ac.After == nil || strings.Contains(cand, ".") {
+				continue
+			}
+
+			if checkShim(renderShim(pc, append(decls, funcShim(name, sig, cand))), pc.After.TypesPkg) != nil {
+				continue
+			}
+
+			// Restored types are aliases in the shim, so the signatures are compared in it
+			if checkShim(renderShim(pc, append(decls, signatureCheck(sig, cand))), pc.After.TypesPkg) == nil {
+				identical = append(identical, cand)
+			} else {
+				compatible = append(compatible, cand)
+			}
+		}
+
+		to, reason := pickShimTarget(identical, "func of the identical signature")
+		if reason != "" {
+			if len(identical) == 0 && len(compatible) > 0 {
+				reason += "; candidates accepting the arguments and returning the results: " + strings.Join(compatible, ", ")
+			}
+			shim.Skipped[name] = reason
+		} else {
+			shim.Restored[name] = to
+			decls = append(decls, funcShim(name, sig, to))
+		}
+	}
+
+	if len(decls) == 0 {
+		return shim, nil
+	}
+
+	src := renderShim(pc, decls)
+	if err := checkShim(src, pc.After.TypesPkg); err != nil {
+		return nil, fmt.Errorf("shim does not compile: %s", err)
+	}
+
+	shim.Source = []byte(src)
+	return shim, nil
+}
+
+// pickShimTarget returns the only candidate, or the reason why none is picked.
+func pickShimTarget(candidates []string, what string) (string, string) {
+	switch len(candidates) {
+	case 0:
+		return "", "no added " + what
+	case 1:
+		return candidates[0], ""
+	default:
+		sort.Strings(candidates)
+		return "", "ambiguous: " + strings.Join(candidates, ", ")
+	}
+}
+
+// unrestoredTypes returns the names of the types of the package at path, which are referred to
+// by the type and removed by the change but not restored.
+func unrestoredTypes(t types.Type, path string, typeChanges map[string]Change, restored map[string]string) []string {
+	names := map[string]bool{}
+
+	var walk func(t types.Type)
+	walk = func(t types.Type) {
+		switch t := t.(type) {
+		case *types.Named:
+			if t.Obj().Pkg() == nil || t.Obj().Pkg().Path() != path {
+				return
+			}
+			name := t.Obj().Name()
+			if c, ok := typeChanges[name]; ok && c.Kind() == ChangeRemoved && restored[name] == "" {
+				names[name] = true
+			}
+		case *types.Pointer:
+			walk(t.Elem())
+		case *types.Slice:
+			walk(t.Elem())
+		case *types.Array:
+			walk(t.Elem())
+		case *types.Map:
+			walk(t.Key())
+			walk(t.Elem())
+		case *types.Chan:
+			walk(t.Elem())
+		case *types.Tuple:
+			for i := 0; i < t.Len(); i++ {
+				walk(t.At(i).Type())
+			}
+		case *types.Signature:
+			walk(t.Params())
+			walk(t.Results())
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				walk(t.Field(i).Type())
+			}
+		case *types.Interface:
+			for i := 0; i < t.NumExplicitMethods(); i++ {
+				walk(t.ExplicitMethod(i).Type())
+			}
+		}
+	}
+	walk(t)
+
+	return util.SortedStringSet(util.MapKeys(names))
+}
+
+// pathTypeString returns the string representation of the type qualified by the package paths,
+// which can be compared between the revisions.
+func pathTypeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Path() })
+}
+
+func aliasShim(name, to string) shimDecl {
+	return func(w *clientWriter) {
+		fmt.Fprintf(&w.buf, "// Deprecated: Use %s instead.\ntype %s = %s\n\n", to, name, to)
+	}
+}
+
+func funcShim(name string, sig *types.Signature, to string) shimDecl {
+	return func(w *clientWriter) {
+		params, args := []string{}, []string{}
+		seen := map[string]bool{to: true}
+		for i := 0; i < sig.Params().Len(); i++ {
+			v := sig.Params().At(i)
+
+			pname := v.Name()
+			if pname == "" || pname == "_" || seen[pname] {
+				pname = fmt.Sprintf("p%d", i)
+			}
+			seen[pname] = true
+
+			if sig.Variadic() && i == sig.Params().Len()-1 {
+				params = append(params, pname+" ..."+w.typeString(v.Type().(*types.Slice).Elem()))
+				args = append(args, pname+"...")
+			} else {
+				params = append(params, pname+" "+w.typeString(v.Type()))
+				args = append(args, pname)
+			}
+		}
+
+		results := []string{}
+		for i := 0; i < sig.Results().Len(); i++ {
+			results = append(results, w.typeString(sig.Results().At(i).Type()))
+		}
+
+		call := fmt.Sprintf("%s(%s)", to, strings.Join(args, ", "))
+
+		fmt.Fprintf(&w.buf, "// Deprecated: Use %s instead.\nfunc %s(%s)", to, name, strings.Join(params, ", "))
+		switch len(results) {
+		case 0:
+			fmt.Fprintf(&w.buf, " {\n\t%s\n}\n\n", call)
+		case 1:
+			fmt.Fprintf(&w.buf, " %s {\n\treturn %s\n}\n\n", results[0], call)
+		default:
+			fmt.Fprintf(&w.buf, " (%s) {\n\treturn %s\n}\n\n", strings.Join(results, ", "), call)
+		}
+	}
+}
+
+// lostMethods returns the names of the exported methods of the removed type t, which its
+// restored declaration name in decls does not have with the identical signatures.
+func lostMethods(pc PackageChanges, decls []shimDecl, name string, t types.Type) []string {
+	methods := exportedMethods(t)
+	if len(methods) == 0 || checkShim(renderShim(pc, append(decls, methodSetCheck(name, t, ""))), pc.After.TypesPkg) == nil {
+		return nil
+	}
+
+	lost := []string{}
+	for _, m := range methods {
+		if checkShim(renderShim(pc, append(decls, methodSetCheck(name, t, m))), pc.After.TypesPkg) != nil {
+			lost = append(lost, m)
+		}
+	}
+
+	return lost
+}
+
+// exportedMethods returns the names of the exported methods of t and *t.
+func exportedMethods(t types.Type) []string {
+	names := map[string]bool{}
+	for _, mset := range []*types.MethodSet{types.NewMethodSet(t), types.NewMethodSet(types.NewPointer(t))} {
+		for i := 0; i < mset.Len(); i++ {
+			if m := mset.At(i).Obj(); m.Exported() {
+				names[m.Name()] = true
+			}
+		}
+	}
+
+	return util.SortedStringSet(util.MapKeys(names))
+}
+
+// methodSetCheck declares variables of the interfaces of the method sets of t and *t, or only
+// of the method only if not empty, assigned the values of name. It compiles only if name has
+// the methods of the identical signatures for both. It is used only for checking candidates.
+func methodSetCheck(name string, t types.Type, only string) shimDecl {
+	return func(w *clientWriter) {
+		check := func(mset *types.MethodSet, value string) {
+			methods := []string{}
+			for i := 0; i < mset.Len(); i++ {
+				m := mset.At(i).Obj()
+				if !m.Exported() || (only != "" && m.Name() != only) {
+					continue
+				}
+				methods = append(methods, m.Name()+strings.TrimPrefix(w.typeString(m.Type()), "func"))
+			}
+			if len(methods) > 0 {
+				fmt.Fprintf(&w.buf, "var _ interface{ %s } = %s\n\n", strings.Join(methods, "; "), value)
+			}
+		}
+
+		check(types.NewMethodSet(t), "*new("+name+")")
+		if !types.IsInterface(t) {
+			check(types.NewMethodSet(types.NewPointer(t)), "new("+name+")")
+		}
+	}
+}
+
+// signatureCheck declares a variable of the signature assigned the func, which compiles
+// only if the func has the identical signature. It is used only for checking candidates.
+func signatureCheck(sig *types.Signature, to string) shimDecl {
+	return func(w *clientWriter) {
+		fmt.Fprintf(&w.buf, "var _ %s = %s\n\n", w.typeString(sig), to)
+	}
+}
+
+// renderShim returns the source of the shim of the declarations.
+func renderShim(pc PackageChanges, decls []shimDecl) string {
+	w := newClientWriter(pc.After.TypesPkg)
+	w.pkgName = pc.After.TypesPkg.Name()
+	w.local = pc.After.TypesPkg.Path()
+
+	for _, decl := range decls {
+		decl(w)
+	}
+
+	return shimHeader + w.String()
+}
+
+// checkShim type-checks the shim as a file of the package. Imported packages are looked up
+// in the packages it depends on, and then resolved by build.Default.
+func checkShim(src string, pkg *types.Package) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "shim.go", src, 0)
+	if err != nil {
+		return err
+	}
+
+	// The shim sees the exported declarations of the package by a dot import,
+	// which also rejects redeclarations of them
+	dot := &ast.ImportSpec{
+		Name: ast.NewIdent("."),
+		Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(pkg.Path())},
+	}
+	file.Decls = append([]ast.Decl{&ast.GenDecl{Tok: token.IMPORT, Specs: []ast.Spec{dot}}}, file.Decls...)
+
+	packages := map[string]*types.Package{}
+	collectImports(pkg, packages)
+	fallback := importer.ForCompiler(fset, "source", nil)
+
+	conf := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if p, ok := packages[path]; ok {
+				return p, nil
+			}
+			return fallback.Import(path)
+		}),
+	}
+
+	_, err = conf.Check(pkg.Path()+"_shim", fset, []*ast.File{file}, nil)
+	return err
+}
@@ -0,0 +1,114 @@
+package gompatible
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateShim(t *testing.T) {
+	lib := fstest.MapFS{
+		"v1/lib.go": {Data: []byte(`package lib
+
+import (
+	"bytes"
+	"io"
+)
+
+type Config struct{ Name string }
+
+func Open(c *Config, r io.Reader, opts ...string) (int, error) { return 0, nil }
+
+func Gone() {}
+
+func Count(r *bytes.Buffer) int { return 0 }
+
+type Handle int
+
+func Use(h Handle) {}
+
+type T struct{}
+
+func (T) M() {}
+
+type Cfg struct{ N int }
+
+func (c Cfg) Valid() bool { return true }
+
+type Buf struct{ B []byte }
+
+func (Buf) Len() int { return 0 }
+
+type Closer struct{ C bool }
+
+func (*Closer) Close() error { return nil }
+`)},
+		"v2/lib.go": {Data: []byte(`package lib
+
+import "io"
+
+type Options struct{ Name string }
+
+func OpenReader(o *Options, r io.Reader, opts ...string) (int, error) { return 0, nil }
+
+func Close(s string) {}
+
+func CountReader(r io.Reader) int { return 0 }
+
+type Ref string
+
+func UseRef(r Ref) {}
+
+type T struct{}
+
+type Conf struct{ N int }
+
+func (c Conf) Valid() bool { return true }
+
+type Buffer struct{ B []byte }
+
+func (*Buffer) Len() int { return 0 }
+
+type Closing struct{ C bool }
+`)},
+	}
+
+	pkgs1, err := LoadFS(lib, "v1", "example.com/lib", false)
+	require.NoError(t, err)
+	pkgs2, err := LoadFS(lib, "v2", "example.com/lib", false)
+	require.NoError(t, err)
+
+	shim, err := GenerateShim(DiffPackages(pkgs1["example.com/lib"], pkgs2["example.com/lib"]))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"Cfg": "Conf", "Config": "Options", "Open": "OpenReader"}, shim.Restored)
+	assert.Equal(t, map[string]string{
+		"Buf":    "no added type of the identical underlying type; candidates lacking methods: Buffer (Len)",
+		"Closer": "no added type of the identical underlying type; candidates lacking methods: Closing (Close)",
+		"Count":  "no added func of the identical signature; candidates accepting the arguments and returning the results: CountReader",
+		"Gone":   "no added func of the identical signature",
+		"Handle": "no added type of the identical underlying type",
+		"Use":    "uses the removed types not restored: Handle",
+		"T.M":    "methods are not supported",
+	}, shim.Skipped)
+
+	assert.Equal(t, `// Compatibility shims restoring the removed APIs, generated by gompat shim.
+
+package lib
+
+import "io"
+
+// Deprecated: Use Conf instead.
+type Cfg = Conf
+
+// Deprecated: Use Options instead.
+type Config = Options
+
+// Deprecated: Use OpenReader instead.
+func Open(c *Config, r io.Reader, opts ...string) (int, error) {
+	return OpenReader(c, r, opts...)
+}
+`, string(shim.Source))
+}
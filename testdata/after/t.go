@@ -68,3 +68,17 @@ type CompatibleT5 <-chan struct{}
 func Compatible3(b io.Reader)
 
 func Compatible4() *bytes.Buffer
+
+// Unchanged4 does nothing.
+//
+// Deprecated: Use Unchanged1 instead.
+func Unchanged4(n int)
+
+//gompat:experimental
+func Breaking5(s string)
+
+//gompat:stable
+func Breaking6(n int, opts ...string)
+
+//gompat:ignore
+func Ignored1(s string)
@@ -64,3 +64,14 @@ type CompatibleT5 chan struct{}
 func Compatible3(b *bytes.Buffer)
 
 func Compatible4() io.Reader
+
+func Unchanged4(n int)
+
+//gompat:experimental
+func Breaking5(n int)
+
+//gompat:stable
+func Breaking6(n int)
+
+//gompat:ignore
+func Ignored1(n int)
@@ -0,0 +1,19 @@
+package testdata_test
+
+import (
+	"testing"
+
+	"testdata"
+)
+
+func ExampleBreaking1() {
+	testdata.Breaking1(1)
+}
+
+func TestT(t *testing.T) {
+	testdata.Unchanged1(1)
+	testdata.Removed1()
+
+	var v testdata.BreakingT1
+	t.Log(v.XXX)
+}
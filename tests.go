@@ -0,0 +1,221 @@
+package gompatible
+
+import (
+	"fmt"
+	"sort"
+
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// TestFailure is an error type-checking the external tests and examples of the package
+// before the change against the package after the change, which is evidence of a breaking change
+// from the client code the package ships with.
+type TestFailure struct {
+	// Name is the name of the API the failure is attributed to, eg. "Func" or "Type.Method",
+	// or an empty string if it could not be attributed to any of the changes
+	Name   string
+	Change Change
+	// Pos is the position of the error in the test file, relative to the repository root if known
+	Pos token.Position
+	Err types.Error
+}
+
+func (f *TestFailure) String() string {
+	return fmt.Sprintf("%s: %s", f.Pos, f.Err.Msg)
+}
+
+// CheckTests type-checks the files of the external test package (package foo_test),
+// including Example functions, of the package before the change against the package after the change,
+// and returns the errors attributed to the changes of the APIs the erroneous code refers to.
+// Errors which also occur against the package before the change, eg. of identifiers
+// exported only to tests, are ignored. Other imports of the tests are resolved by build.Default.
+func CheckTests(pc PackageChanges) []*TestFailure {
+	if pc.Before == nil || pc.After == nil {
+		return nil
+	}
+
+	files := pc.Before.testFiles()
+	if len(files) == 0 {
+		return nil
+	}
+
+	fset := pc.Before.Fset
+	fallback := importer.ForCompiler(fset, "source", nil)
+
+	info := &types.Info{
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	path := pc.Before.TypesPkg.Path()
+	_, errsBefore := checkClientFiles(fset, files, path+"_test", path, pc.Before.TypesPkg, nil, fallback, info)
+	_, errsAfter := checkClientFiles(fset, files, path+"_test", path, pc.After.TypesPkg, pc.Before.TypesPkg, fallback, nil)
+
+	names := changedObjectNames(pc)
+
+	failures := []*TestFailure{}
+	for _, err := range newErrors(errsBefore, errsAfter) {
+		f := &TestFailure{
+			Pos: pc.Before.Position(err.Pos),
+			Err: err,
+		}
+		if name, _ := attributeError(files, err.Pos, info, names); name != "" {
+			f.Name = name
+			f.Change = findChange(pc, name)
+		}
+
+		failures = append(failures, f)
+	}
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Err.Pos < failures[j].Err.Pos
+	})
+
+	return failures
+}
+
+// checkClientFiles type-checks the files of the package at path, which import the target package
+// at importPath, and returns the package and the errors. Other imported packages are looked up in
+// the packages target depends on, in extra and its dependencies, and then by fallback.
+func checkClientFiles(fset *token.FileSet, files []*ast.File, path, importPath string, target, extra *types.Package, fallback types.Importer, info *types.Info) (*types.Package, []types.Error) {
+	packages := map[string]*types.Package{}
+	if extra != nil {
+		collectImports(extra, packages)
+	}
+	collectImports(target, packages)
+	packages[importPath] = target
+
+	errs := []types.Error{}
+	conf := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if pkg, ok := packages[path]; ok {
+				return pkg, nil
+			}
+			return fallback.Import(path)
+		}),
+		Error: func(err error) {
+			if err, ok := err.(types.Error); ok {
+				errs = append(errs, err)
+			}
+		},
+	}
+
+	pkg, _ := conf.Check(path, fset, files, info)
+	return pkg, errs
+}
+
+// newErrors returns the errors in after which do not occur at the same positions in before.
+func newErrors(before, after []types.Error) []types.Error {
+	seen := map[token.Pos]bool{}
+	for _, err := range before {
+		seen[err.Pos] = true
+	}
+
+	errs := []types.Error{}
+	for _, err := range after {
+		if !seen[err.Pos] {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// changedObjectNames maps the objects of the package before the change to the names of their changes.
+func changedObjectNames(pc PackageChanges) map[types.Object]string {
+	names := map[types.Object]string{}
+	for _, changes := range pc.Changes {
+		for name, c := range changes {
+			if c.Kind() == ChangeUnchanged {
+				continue
+			}
+
+			// Added APIs cannot be referred to
+			switch c := c.(type) {
+			case FuncChange:
+				if c.Before != nil {
+					names[c.Before.Types] = name
+				}
+			case TypeChange:
+				if c.Before != nil {
+					names[c.Before.Types] = name
+				}
+			case ValueChange:
+				if c.Before != nil {
+					names[c.Before.Types] = name
+				}
+			}
+		}
+	}
+	return names
+}
+
+// findChange returns the change of the API name.
+func findChange(pc PackageChanges, name string) Change {
+	for _, changes := range pc.Changes {
+		if c, ok := changes[name]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// attributeError finds the changed API the code at pos refers to, and the position of the reference.
+// It looks for references in the nodes enclosing pos from the innermost one up to the statement or declaration.
+func attributeError(files []*ast.File, pos token.Pos, info *types.Info, names map[types.Object]string) (string, token.Pos) {
+	for _, file := range files {
+		if pos < file.Pos() || file.End() < pos {
+			continue
+		}
+
+		path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+		for _, node := range path {
+			if name, ref := referredChange(node, info, names); name != "" {
+				return name, ref
+			}
+
+			switch node.(type) {
+			case ast.Stmt, ast.Decl:
+				return "", token.NoPos
+			}
+		}
+	}
+
+	return "", token.NoPos
+}
+
+// referredChange returns the name of the first changed API referred to in the node, and the position
+// of the reference. Fields are attributed to the types they belong to.
+func referredChange(node ast.Node, info *types.Info, names map[types.Object]string) (name string, ref token.Pos) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if name != "" {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if sel, ok := info.Selections[n]; ok && sel.Kind() == types.FieldVal {
+				if named, ok := derefNamed(sel.Recv()); ok {
+					name, ref = names[named.Obj()], n.Sel.Pos()
+				}
+			}
+		case *ast.Ident:
+			name, ref = names[info.Uses[n]], n.Pos()
+		}
+
+		return name == ""
+	})
+
+	return
+}
+
+func derefNamed(t types.Type) (*types.Named, bool) {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return named, ok
+}
@@ -0,0 +1,35 @@
+package gompatible
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTests(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	// Test files are parsed only when checked
+	assert.False(t, pkgs1["testdata"].testsParsed)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	failures := CheckTests(diff)
+	assert.True(t, pkgs1["testdata"].testsParsed)
+	require.Len(t, failures, 3)
+
+	names := []string{}
+	for _, f := range failures {
+		names = append(names, f.Name)
+		assert.NotNil(t, f.Change, f.String())
+		assert.Equal(t, "t_test.go", filepath.Base(f.Pos.Filename))
+	}
+	assert.Equal(t, []string{"Breaking1", "Removed1", "BreakingT1"}, names)
+
+	assert.Empty(t, CheckTests(DiffPackages(pkgs1["testdata"], pkgs1["testdata"])))
+}
@@ -1,6 +1,7 @@
 package gompatible
 
 import (
+	"go/token"
 	"go/types"
 
 	_ "golang.org/x/tools/go/gcimporter15"
@@ -32,7 +33,29 @@ func (tc TypeChange) ShowAfter() string {
 	return t.Package.showASTNode(t.Doc.Decl)
 }
 
+func (tc TypeChange) PosBefore() token.Position {
+	t := tc.Before
+	if t == nil {
+		return token.Position{}
+	}
+	return t.Package.Position(t.Types.Pos())
+}
+
+func (tc TypeChange) PosAfter() token.Position {
+	t := tc.After
+	if t == nil {
+		return token.Position{}
+	}
+	return t.Package.Position(t.Types.Pos())
+}
+
+// Kind returns the kind of the change. Compatible changes of APIs
+// marked stable by the directive are considered breaking.
 func (tc TypeChange) Kind() ChangeKind {
+	return strictKind(tc.kind(), HasDirective(tc, DirectiveStable))
+}
+
+func (tc TypeChange) kind() ChangeKind {
 	switch {
 	case tc.Before == nil && tc.After == nil:
 		// might not happen
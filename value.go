@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"go/ast"
+	"go/token"
 	"go/types"
 )
 
@@ -80,7 +81,29 @@ func (vc ValueChange) ShowAfter() string {
 	}
 }
 
+func (vc ValueChange) PosBefore() token.Position {
+	v := vc.Before
+	if v == nil {
+		return token.Position{}
+	}
+	return v.Package.Position(v.Types.Pos())
+}
+
+func (vc ValueChange) PosAfter() token.Position {
+	v := vc.After
+	if v == nil {
+		return token.Position{}
+	}
+	return v.Package.Position(v.Types.Pos())
+}
+
+// Kind returns the kind of the change. Compatible changes of APIs
+// marked stable by the directive are considered breaking.
 func (vc ValueChange) Kind() ChangeKind {
+	return strictKind(vc.kind(), HasDirective(vc, DirectiveStable))
+}
+
+func (vc ValueChange) kind() ChangeKind {
 	switch {
 	case vc.Before == nil && vc.After == nil:
 		// might not happen
@@ -0,0 +1,115 @@
+package gompatible
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"sourcegraph.com/sourcegraph/go-vcs/vcs"
+)
+
+// A VCSBackend is a version control system which source trees of revisions are read from.
+// Backends other than git and Mercurial can be added by RegisterVCS.
+type VCSBackend interface {
+	// Name returns the name of the VCS, eg. "git", which is used as DirSpec.VCS.
+	Name() string
+	// Root returns the root directory of the repository which dir belongs to.
+	Root(dir string) (string, error)
+	// Open opens the repository at the root directory.
+	Open(root string) (vcs.Repository, error)
+}
+
+var (
+	vcsBackendsMu sync.RWMutex
+	vcsBackends   = []VCSBackend{
+		commandVCSBackend{name: "git", rootCmd: []string{"git", "rev-parse", "--show-toplevel"}},
+		commandVCSBackend{name: "hg", rootCmd: []string{"hg", "root"}},
+	}
+)
+
+// RegisterVCS registers the VCS backend. A backend registered later
+// takes precedence over the existing one of the same name.
+func RegisterVCS(b VCSBackend) {
+	vcsBackendsMu.Lock()
+	defer vcsBackendsMu.Unlock()
+
+	vcsBackends = append([]VCSBackend{b}, vcsBackends...)
+}
+
+// LookupVCS returns the VCS backend registered by the name, or nil if none.
+func LookupVCS(name string) VCSBackend {
+	vcsBackendsMu.RLock()
+	defer vcsBackendsMu.RUnlock()
+
+	for _, b := range vcsBackends {
+		if b.Name() == name {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// DetectVCS finds the VCS of the repository which dir belongs to and returns
+// the backend and the root directory of the repository.
+// If dir is inside nested repositories, the innermost one is chosen.
+func DetectVCS(dir string) (VCSBackend, string, error) {
+	vcsBackendsMu.RLock()
+	defer vcsBackendsMu.RUnlock()
+
+	var (
+		found VCSBackend
+		root  string
+	)
+
+	seen := map[string]bool{}
+	for _, b := range vcsBackends {
+		if seen[b.Name()] {
+			continue
+		}
+		seen[b.Name()] = true
+
+		r, err := b.Root(dir)
+		if err != nil {
+			continue
+		}
+
+		if len(r) > len(root) {
+			found, root = b, r
+		}
+	}
+
+	if found == nil {
+		return nil, "", fmt.Errorf("%s: not in a repository of any of known VCS", dir)
+	}
+
+	return found, root, nil
+}
+
+// commandVCSBackend is a VCSBackend which finds the root by a command,
+// and opens repositories by go-vcs.
+type commandVCSBackend struct {
+	name    string
+	rootCmd []string
+}
+
+func (b commandVCSBackend) Name() string {
+	return b.name
+}
+
+func (b commandVCSBackend) Root(dir string) (string, error) {
+	cmd := exec.Command(b.rootCmd[0], b.rootCmd[1:]...)
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+func (b commandVCSBackend) Open(root string) (vcs.Repository, error) {
+	return vcs.Open(b.name, root)
+}
@@ -0,0 +1,105 @@
+package gompatible
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sourcegraph.com/sourcegraph/go-vcs/vcs"
+)
+
+// markerVCSBackend is a fake VCS whose repository root has a marker file.
+type markerVCSBackend struct{}
+
+func (markerVCSBackend) Name() string { return "marker" }
+
+func (markerVCSBackend) Root(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".marker")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("not in a marker repository")
+		}
+		dir = parent
+	}
+}
+
+func (markerVCSBackend) Open(root string) (vcs.Repository, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// registerTestVCS registers the backend until the test finishes.
+func registerTestVCS(t *testing.T, b VCSBackend) {
+	vcsBackendsMu.RLock()
+	saved := vcsBackends
+	vcsBackendsMu.RUnlock()
+
+	t.Cleanup(func() {
+		vcsBackendsMu.Lock()
+		defer vcsBackendsMu.Unlock()
+		vcsBackends = saved
+	})
+
+	RegisterVCS(b)
+}
+
+func TestDetectVCS(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	require.NoError(t, err)
+
+	if _, err := exec.LookPath("git"); err == nil {
+		gitDir := filepath.Join(tempDir, "git")
+		require.NoError(t, os.MkdirAll(filepath.Join(gitDir, "sub"), 0755))
+		git(t, gitDir, "init", "-q")
+
+		backend, root, err := DetectVCS(filepath.Join(gitDir, "sub"))
+		require.NoError(t, err)
+		assert.Equal(t, "git", backend.Name())
+		assert.Equal(t, gitDir, root)
+
+		registerTestVCS(t, markerVCSBackend{})
+
+		nested := filepath.Join(gitDir, "sub")
+		require.NoError(t, ioutil.WriteFile(filepath.Join(nested, ".marker"), nil, 0644))
+
+		backend, root, err = DetectVCS(nested)
+		require.NoError(t, err)
+		assert.Equal(t, "marker", backend.Name())
+		assert.Equal(t, nested, root)
+	}
+
+	if _, err := exec.LookPath("hg"); err == nil {
+		hgDir := filepath.Join(tempDir, "hg")
+		require.NoError(t, os.MkdirAll(filepath.Join(hgDir, "sub"), 0755))
+
+		cmd := exec.Command("hg", "init")
+		cmd.Dir = hgDir
+		require.NoError(t, cmd.Run())
+
+		backend, root, err := DetectVCS(filepath.Join(hgDir, "sub"))
+		require.NoError(t, err)
+		assert.Equal(t, "hg", backend.Name())
+		assert.Equal(t, hgDir, root)
+	}
+
+	_, _, err = DetectVCS(tempDir)
+	assert.Error(t, err)
+}
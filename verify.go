@@ -0,0 +1,110 @@
+package gompatible
+
+import (
+	"go/types"
+
+	"github.com/motemen/gompatible/internal/util"
+)
+
+// Verification is the result of verifying the classification of a change
+// by type-checking a client program, which uses the API before the change,
+// against the packages before and after the change.
+type Verification struct {
+	// Name is the name of the API, eg. "Func" or "Type.Method"
+	Name   string
+	Change Change
+	// Kind is the classification verified, by the types regardless of the directives
+	Kind ChangeKind
+	// Client is the source of the synthesized client program
+	Client string
+	// ErrBefore is the error type-checking the client against the package before the change.
+	// If it is not nil, the verification is inconclusive.
+	ErrBefore error
+	// ErrAfter is the error type-checking the client against the package after the change.
+	ErrAfter error
+}
+
+// Inconclusive reports whether the client could not be verified,
+// eg. when the API refers to unexported types.
+func (v *Verification) Inconclusive() bool {
+	return v.ErrBefore != nil
+}
+
+// Mismatch reports whether the classification of the change is contradicted by the client:
+// it must fail to compile after breaking changes and removals, and must compile after others.
+func (v *Verification) Mismatch() bool {
+	if v.Inconclusive() {
+		return false
+	}
+
+	switch v.Kind {
+	case ChangeBreaking, ChangeRemoved:
+		return v.ErrAfter == nil
+	default:
+		return v.ErrAfter != nil
+	}
+}
+
+// Verify verifies the classifications of the changes of the package. For each change,
+// it synthesizes a client program exercising the API before the change and type-checks it
+// against the packages before and after the change. The classification verified is
+// the one by the types, regardless of the directives. Added APIs, which have nothing to be
+// verified, and changes of added or removed packages are skipped.
+func Verify(pc PackageChanges) []*Verification {
+	if pc.Before == nil || pc.After == nil {
+		return nil
+	}
+
+	verifications := []*Verification{}
+
+	for _, cat := range []ObjectCategory{ObjectCategoryFunc, ObjectCategoryType, ObjectCategoryValue} {
+		changes := pc.Changes[cat]
+		for _, name := range util.SortedStringSet(util.MapKeys(changes)) {
+			if v := verify(changes[name], pc.Before, pc.After); v != nil {
+				v.Name = name
+				verifications = append(verifications, v)
+			}
+		}
+	}
+
+	return verifications
+}
+
+func verify(c Change, before, after *Package) *Verification {
+	var (
+		kind ChangeKind
+		obj  types.Object
+	)
+	switch c := c.(type) {
+	case FuncChange:
+		if c.Before == nil {
+			return nil
+		}
+		kind, obj = c.kind(), c.Before.Types
+	case TypeChange:
+		if c.Before == nil {
+			return nil
+		}
+		kind, obj = c.kind(), c.Before.Types
+	case ValueChange:
+		if c.Before == nil {
+			return nil
+		}
+		kind, obj = c.kind(), c.Before.Types
+	default:
+		return nil
+	}
+
+	client := newClientWriter(before.TypesPkg)
+	for _, snippet := range clientSnippets(obj, false) {
+		client.writeSnippet(snippet)
+	}
+
+	return &Verification{
+		Change:    c,
+		Client:    client.String(),
+		ErrBefore: client.check(before.TypesPkg, nil),
+		ErrAfter:  client.check(after.TypesPkg, before.TypesPkg),
+		Kind:      kind,
+	}
+}
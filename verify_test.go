@@ -0,0 +1,44 @@
+package gompatible
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	pkgs1, err := LoadDir(&DirSpec{Path: "testdata/before", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+	pkgs2, err := LoadDir(&DirSpec{Path: "testdata/after", pkgOverride: "testdata"}, false)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["testdata"], pkgs2["testdata"])
+
+	verifications := map[string]*Verification{}
+	for _, v := range Verify(diff) {
+		verifications[v.Name] = v
+	}
+
+	assert.NotContains(t, verifications, "Added1")
+
+	for _, name := range []string{"Breaking1", "Compatible1", "Compatible3", "Removed1", "BreakingT1", "CompatibleT4", "BreakingV2"} {
+		v := verifications[name]
+		require.NotNil(t, v, name)
+		assert.False(t, v.Inconclusive(), "%s:\n%s\n%v", name, v.Client, v.ErrBefore)
+		assert.False(t, v.Mismatch(), "%s:\n%s\n%v", name, v.Client, v.ErrAfter)
+	}
+
+	assert.Error(t, verifications["Breaking1"].ErrAfter)
+	assert.NoError(t, verifications["Compatible1"].ErrAfter)
+
+	// Structs with fields added are compatible as long as clients do not use unkeyed literals
+	for _, name := range []string{"CompatibleT1", "UnchangedT4"} {
+		v := verifications[name]
+		require.NotNil(t, v, name)
+		assert.False(t, v.Mismatch(), "%s:\n%s\n%v", name, v.Client, v.ErrAfter)
+	}
+
+	// Assigning to a variable of a widened struct type does not compile
+	assert.True(t, verifications["CompatibleV1"].Mismatch())
+}
@@ -0,0 +1,111 @@
+package gompatible
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+// NewZipDirSpec creates a virtual directory at subdir in the zip archive zipFile,
+// subdir being slash-separated and relative to the root of the archive.
+// If the archive is a module zip, whose files are under "<module>@<version>/"
+// as in the module cache, subdir is relative to the module root and
+// ImportPath is set to the import path of the directory in the module.
+func NewZipDirSpec(zipFile, subdir string) (*DirSpec, error) {
+	root, err := filepath.Abs(zipFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fsys, modulePath, err := openZipFS(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi, err := fs.Stat(fsys, subdir); err != nil || fi.IsDir() == false {
+		return nil, fmt.Errorf("%s: no such directory in %s", subdir, zipFile)
+	}
+
+	dir := &DirSpec{
+		Zip:  root,
+		FS:   fsys,
+		Path: filepath.Join(root, filepath.FromSlash(subdir)),
+		root: root,
+	}
+
+	if modulePath != "" {
+		dir.ImportPath = path.Join(modulePath, subdir)
+	}
+
+	if _, err := dir.mount(); err != nil {
+		return nil, err
+	}
+
+	return dir, nil
+}
+
+// openZipFS opens the zip archive as an fs.FS. If it is a module zip,
+// the module root is returned with the module path.
+func openZipFS(name string) (fs.FS, string, error) {
+	// Read whole the archive not to keep the file open
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %s", name, err)
+	}
+
+	prefix := moduleZipPrefix(r.File)
+	if prefix == "" {
+		return r, "", nil
+	}
+
+	modulePath, err := module.UnescapePath(prefix[:strings.LastIndex(prefix, "@")])
+	if err != nil {
+		// not a module zip
+		return r, "", nil
+	}
+
+	fsys, err := fs.Sub(r, prefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fsys, modulePath, nil
+}
+
+// moduleZipPrefix returns "<module>@<version>" if all the files are under it.
+func moduleZipPrefix(files []*zip.File) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	at := strings.IndexByte(files[0].Name, '@')
+	if at == -1 {
+		return ""
+	}
+
+	slash := strings.IndexByte(files[0].Name[at:], '/')
+	if slash == -1 {
+		return ""
+	}
+
+	prefix := files[0].Name[:at+slash]
+	for _, f := range files {
+		if strings.HasPrefix(f.Name, prefix+"/") == false {
+			return ""
+		}
+	}
+
+	return prefix
+}
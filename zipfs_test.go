@@ -0,0 +1,92 @@
+package gompatible
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestZipDirSpec(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gompat")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	zip1 := filepath.Join(tempDir, "v1.0.0.zip")
+	writeZip(t, zip1, map[string]string{
+		"example.com/!foo@v1.0.0/go.mod":   "module example.com/Foo\n",
+		"example.com/!foo@v1.0.0/a.go":     "package foo\n\nfunc A() {}\n",
+		"example.com/!foo@v1.0.0/sub/b.go": "package sub\n\nfunc B() {}\n",
+	})
+
+	zip2 := filepath.Join(tempDir, "v1.1.0.zip")
+	writeZip(t, zip2, map[string]string{
+		"example.com/!foo@v1.1.0/go.mod":   "module example.com/Foo\n",
+		"example.com/!foo@v1.1.0/a.go":     "package foo\n\nfunc A(n int) {}\n",
+		"example.com/!foo@v1.1.0/sub/b.go": "package sub\n\nfunc B() {}\n",
+	})
+
+	dir1, err := NewZipDirSpec(zip1, ".")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/Foo", dir1.ImportPath)
+
+	pkgs1, err := LoadDir(dir1, true)
+	require.NoError(t, err)
+	require.Contains(t, pkgs1, "example.com/Foo")
+	require.Contains(t, pkgs1, "example.com/Foo/sub")
+
+	pkg := pkgs1["example.com/Foo/sub"]
+	assert.Equal(t, "sub/b.go", pkg.Position(pkg.Funcs["B"].Types.Pos()).Filename)
+
+	dir2, err := NewZipDirSpec(zip2, ".")
+	require.NoError(t, err)
+
+	pkgs2, err := LoadDir(dir2, true)
+	require.NoError(t, err)
+
+	diff := DiffPackages(pkgs1["example.com/Foo"], pkgs2["example.com/Foo"])
+	assert.Equal(t, ChangeBreaking, diff.Funcs()["A"].Kind())
+
+	dir, err := NewZipDirSpec(zip1, "sub")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/Foo/sub", dir.ImportPath)
+
+	pkgs, err := LoadDir(dir, false)
+	require.NoError(t, err)
+	assert.Contains(t, pkgs, "example.com/Foo/sub")
+
+	_, err = NewZipDirSpec(zip1, "nonexistent")
+	assert.Error(t, err)
+
+	plain := filepath.Join(tempDir, "plain.zip")
+	writeZip(t, plain, map[string]string{
+		"a.go": "package foo\n\nfunc A() {}\n",
+	})
+
+	dir, err = NewZipDirSpec(plain, ".")
+	require.NoError(t, err)
+	dir.ImportPath = "foo"
+
+	pkgs, err = LoadDir(dir, false)
+	require.NoError(t, err)
+	assert.Contains(t, pkgs, "foo")
+}